@@ -0,0 +1,91 @@
+package api
+
+import (
+	"crypto/subtle"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/awoodbeck/faang-stonks/history"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// symbolList is the response body of the admin symbol endpoints.
+type symbolList struct {
+	Symbols []string `json:"symbols"`
+}
+
+// bearerAuth rejects requests whose Authorization header doesn't carry the
+// given bearer token.
+func bearerAuth(token string) mux.MiddlewareFunc {
+	expected := []byte("Bearer " + token)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				actual := []byte(r.Header.Get("Authorization"))
+				if subtle.ConstantTimeCompare(actual, expected) != 1 {
+					w.Header().Set("WWW-Authenticate", `Bearer realm="stonks"`)
+					w.WriteHeader(http.StatusUnauthorized)
+					_, _ = w.Write([]byte("Unauthorized"))
+					return
+				}
+
+				next.ServeHTTP(w, r)
+			},
+		)
+	}
+}
+
+// adminSymbols adds the stock symbol in the request URI to the registry, or
+// removes it, depending on the request method, and responds with the
+// registered symbols. GET requests leave the registry as-is.
+func adminSymbols(reg history.SymbolRegistry,
+	log *zap.SugaredLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		_, _ = io.Copy(io.Discard, r.Body)
+		_ = r.Body.Close()
+
+		symbol := strings.ToLower(mux.Vars(r)["symbol"])
+
+		switch r.Method {
+		case http.MethodPost:
+			err = reg.AddSymbol(r.Context(), symbol)
+			if err == nil {
+				log.Infof("added symbol %q", symbol)
+			}
+		case http.MethodDelete:
+			err = reg.RemoveSymbol(r.Context(), symbol)
+			if err == nil {
+				log.Infof("removed symbol %q", symbol)
+			}
+		}
+		if err != nil {
+			if err == history.ErrNotFound {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte("Not found"))
+			} else {
+				log.Error(err, zap.String("url", r.URL.String()))
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte("Internal server error"))
+			}
+			return
+		}
+
+		list := symbolList{}
+		list.Symbols, err = reg.Symbols(r.Context())
+		if err != nil {
+			log.Error(err, zap.String("url", r.URL.String()))
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("Internal server error"))
+			return
+		}
+		if list.Symbols == nil {
+			list.Symbols = []string{}
+		}
+
+		writeJSON(w, http.StatusOK, list, log)
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/awoodbeck/faang-stonks/history/memory"
+)
+
+func TestAdminSymbolsHandler(t *testing.T) {
+	t.Parallel()
+
+	registry, err := memory.New(memory.Symbols([]string{"fb"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{log: log, registry: registry, adminToken: "secret"}
+	r := s.newMux(provider)
+
+	for _, token := range []string{"", "Bearer", "Bearer wrong", "secret"} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/symbols/goog", nil)
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q results in code: %q", token,
+				http.StatusText(w.Code))
+		}
+	}
+
+	for _, tc := range []struct {
+		method, uri string
+		code        int
+		expected    []string
+	}{
+		{http.MethodPost, "/v1/admin/symbols/GOOG", http.StatusOK, []string{"fb", "goog"}},
+		{http.MethodPost, "/v1/admin/symbols/goog", http.StatusOK, []string{"fb", "goog"}},
+		{http.MethodDelete, "/v1/admin/symbols/fb", http.StatusOK, []string{"goog"}},
+		{http.MethodDelete, "/v1/admin/symbols/fb", http.StatusNotFound, nil},
+		{http.MethodPost, "/v1/admin/symbols/go*g", http.StatusNotFound, nil},
+		{http.MethodGet, "/v1/admin/symbols", http.StatusOK, []string{"goog"}},
+	} {
+		req := httptest.NewRequest(tc.method, tc.uri, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.code {
+			t.Errorf("%s %s results in code: %q", tc.method, tc.uri,
+				http.StatusText(w.Code))
+			continue
+		}
+		if tc.code != http.StatusOK {
+			continue
+		}
+
+		var actual symbolList
+		if err := json.NewDecoder(w.Body).Decode(&actual); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(actual.Symbols, tc.expected) {
+			t.Errorf("%s %s: actual symbols: %v; expected: %v", tc.method,
+				tc.uri, actual.Symbols, tc.expected)
+		}
+	}
+
+	// GET /v1/stocks tracks the registered symbols.
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/stocks?symbols=fb", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("removed symbol results in code: %q", http.StatusText(w.Code))
+	}
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/awoodbeck/faang-stonks/history"
+)
+
+// view converts a value into the form the server encodes it in as JSON.
+type view func(v interface{}) interface{}
+
+// plain encodes values as they are.
+func plain(v interface{}) interface{} { return v }
+
+// decimalStrings encodes the prices in quotes and candles as JSON strings.
+// Other values are encoded as they are.
+func decimalStrings(v interface{}) interface{} {
+	switch v := v.(type) {
+	case finance.Quote:
+		return newStringQuote(v)
+	case []finance.Quote:
+		return newStringQuotes(v)
+	case finance.QuoteBatch:
+		return newStringBatch(v)
+	case []history.Candle:
+		candles := make([]stringCandle, len(v))
+		for i, c := range v {
+			candles[i] = stringCandle{
+				Symbol: c.Symbol,
+				Time:   c.Time,
+				Open:   stringDecimal(c.Open),
+				High:   stringDecimal(c.High),
+				Low:    stringDecimal(c.Low),
+				Close:  stringDecimal(c.Close),
+				Count:  c.Count,
+			}
+		}
+		return candles
+	case partialBatch:
+		return struct {
+			Quotes map[string][]stringQuote `json:"quotes,omitempty"`
+			Errors map[string]symbolError   `json:"errors"`
+		}{newStringBatch(v.Quotes), v.Errors}
+	case wsQuote:
+		return struct {
+			Type  string      `json:"type"`
+			Quote stringQuote `json:"quote"`
+		}{v.Type, newStringQuote(v.Quote)}
+	}
+
+	return v
+}
+
+// stringDecimal is a finance.Decimal that marshals to a JSON string (e.g.,
+// "123.42").
+type stringDecimal finance.Decimal
+
+// MarshalJSON encodes d as a JSON string.
+func (d stringDecimal) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + finance.Decimal(d).String() + `"`), nil
+}
+
+// stringQuote is a finance.Quote whose prices marshal to JSON strings.
+type stringQuote struct {
+	Price    stringDecimal `json:"price"`
+	Symbol   string        `json:"symbol"`
+	Time     time.Time     `json:"time"`
+	Provider string        `json:"provider,omitempty"`
+
+	Source           string `json:"source,omitempty"`
+	CalculationPrice string `json:"calculationPrice,omitempty"`
+
+	Open          *stringDecimal `json:"open,omitempty"`
+	High          *stringDecimal `json:"high,omitempty"`
+	Low           *stringDecimal `json:"low,omitempty"`
+	Close         *stringDecimal `json:"close,omitempty"`
+	Volume        *int64         `json:"volume,omitempty"`
+	PreviousClose *stringDecimal `json:"previousClose,omitempty"`
+	Change        *stringDecimal `json:"change,omitempty"`
+	ChangePercent *float64       `json:"changePercent,omitempty"`
+	MarketCap     *int64         `json:"marketCap,omitempty"`
+	PERatio       *float64       `json:"peRatio,omitempty"`
+	Week52High    *stringDecimal `json:"week52High,omitempty"`
+	Week52Low     *stringDecimal `json:"week52Low,omitempty"`
+}
+
+func newStringQuote(q finance.Quote) stringQuote {
+	return stringQuote{
+		Price:            stringDecimal(q.Price),
+		Symbol:           q.Symbol,
+		Time:             q.Time,
+		Provider:         q.Provider,
+		Source:           q.Source,
+		CalculationPrice: q.CalculationPrice,
+		Open:             (*stringDecimal)(q.Open),
+		High:             (*stringDecimal)(q.High),
+		Low:              (*stringDecimal)(q.Low),
+		Close:            (*stringDecimal)(q.Close),
+		Volume:           q.Volume,
+		PreviousClose:    (*stringDecimal)(q.PreviousClose),
+		Change:           (*stringDecimal)(q.Change),
+		ChangePercent:    q.ChangePercent,
+		MarketCap:        q.MarketCap,
+		PERatio:          q.PERatio,
+		Week52High:       (*stringDecimal)(q.Week52High),
+		Week52Low:        (*stringDecimal)(q.Week52Low),
+	}
+}
+
+func newStringQuotes(quotes []finance.Quote) []stringQuote {
+	if quotes == nil {
+		return nil
+	}
+
+	s := make([]stringQuote, len(quotes))
+	for i, q := range quotes {
+		s[i] = newStringQuote(q)
+	}
+
+	return s
+}
+
+func newStringBatch(batch finance.QuoteBatch) map[string][]stringQuote {
+	if batch == nil {
+		return nil
+	}
+
+	s := make(map[string][]stringQuote, len(batch))
+	for symbol, quotes := range batch {
+		s[symbol] = newStringQuotes(quotes)
+	}
+
+	return s
+}
+
+// stringCandle is a history.Candle whose prices marshal to JSON strings.
+type stringCandle struct {
+	Symbol string        `json:"symbol"`
+	Time   time.Time     `json:"time"`
+	Open   stringDecimal `json:"open"`
+	High   stringDecimal `json:"high"`
+	Low    stringDecimal `json:"low"`
+	Close  stringDecimal `json:"close"`
+	Count  int           `json:"count"`
+}
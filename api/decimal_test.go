@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/awoodbeck/faang-stonks/history"
+)
+
+// jsonFields returns v's JSON object keys and whether each value is a string.
+func jsonFields(t *testing.T, v interface{}) map[string]bool {
+	t.Helper()
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err = json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+
+	fields := make(map[string]bool, len(m))
+	for k, v := range m {
+		_, fields[k] = v.(string)
+	}
+
+	return fields
+}
+
+func TestDecimalStrings(t *testing.T) {
+	t.Parallel()
+
+	// Populate every field, so the string views must mirror them all.
+	d, i, f := dec("1.5"), int64(2), 3.5
+	q := finance.Quote{Price: dec("123.45"), Symbol: "fb", Time: time.Now(),
+		Provider: "iexcloud", Source: "IEX real time price",
+		CalculationPrice: "tops"}
+	rv := reflect.ValueOf(&q).Elem()
+	for j := 0; j < rv.NumField(); j++ {
+		switch field := rv.Field(j); field.Interface().(type) {
+		case *finance.Decimal:
+			field.Set(reflect.ValueOf(&d))
+		case *int64:
+			field.Set(reflect.ValueOf(&i))
+		case *float64:
+			field.Set(reflect.ValueOf(&f))
+		}
+	}
+	c := history.Candle{Symbol: "fb", Time: time.Now(), Open: d, High: d,
+		Low: d, Close: d, Count: 1}
+
+	for _, tc := range []struct {
+		plain, strings interface{}
+		decimals       []string
+	}{
+		{q, decimalStrings(q), []string{"price", "open", "high", "low",
+			"close", "previousClose", "change", "week52High", "week52Low"}},
+		{c, decimalStrings([]history.Candle{c}).([]stringCandle)[0],
+			[]string{"open", "high", "low", "close"}},
+	} {
+		plain, strs := jsonFields(t, tc.plain), jsonFields(t, tc.strings)
+		if len(plain) != len(strs) {
+			t.Errorf("actual fields: %v; expected: %v", strs, plain)
+		}
+		for _, k := range tc.decimals {
+			if plain[k] || !strs[k] {
+				t.Errorf("%s: plain string: %t; string view string: %t",
+					k, plain[k], strs[k])
+			}
+			delete(plain, k)
+			delete(strs, k)
+		}
+		if !reflect.DeepEqual(plain, strs) {
+			t.Errorf("actual fields: %v; expected: %v", strs, plain)
+		}
+	}
+}
+
+func TestDecimalStringsPerServer(t *testing.T) {
+	t.Parallel()
+
+	// Servers encode prices independently of one another.
+	var bodies []string
+	for _, s := range []*Server{
+		{log: log, symbols: finance.DefaultSymbols, decimalStrings: true},
+		{log: log, symbols: finance.DefaultSymbols},
+	} {
+		w := httptest.NewRecorder()
+		s.newMux(provider).ServeHTTP(w, httptest.NewRequest(http.MethodGet,
+			"/v1/stocks?symbols=fb,tsla&last=1", nil))
+		if w.Code != http.StatusMultiStatus {
+			t.Fatalf("actual code: %d", w.Code)
+		}
+		bodies = append(bodies, w.Body.String())
+	}
+
+	if !strings.Contains(bodies[0], `"price":"123.4"`) {
+		t.Errorf("price not encoded as a string: %s", bodies[0])
+	}
+	if !strings.Contains(bodies[1], `"price":123.4`) {
+		t.Errorf("price not encoded as a number: %s", bodies[1])
+	}
+}
@@ -1,11 +1,15 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/awoodbeck/faang-stonks/finance"
 	"github.com/awoodbeck/faang-stonks/history"
@@ -13,15 +17,112 @@ import (
 	"go.uber.org/zap"
 )
 
-func stock(p history.Provider, log *zap.SugaredLogger) http.HandlerFunc {
+// symbolError describes why the API has no quotes for a requested symbol.
+type symbolError struct {
+	Status int    `json:"status"`
+	Error  string `json:"error"`
+}
+
+// partialBatch is the response body of a batch request for which some
+// symbols have no quotes.
+type partialBatch struct {
+	Quotes finance.QuoteBatch     `json:"quotes,omitempty"`
+	Errors map[string]symbolError `json:"errors"`
+}
+
+// parseRange returns a history.Range built from the request's "from", "to",
+// and "order" query parameters, using last as the range's limit. The boolean
+// is false if the request includes none of those parameters, in which case
+// the caller should fall back to a "last N" query.
+func parseRange(r *http.Request, last int) (history.Range, bool, error) {
+	var (
+		err error
+		rng = history.Range{Limit: last}
+		q   = r.URL.Query()
+	)
+
+	from, to, order := q.Get("from"), q.Get("to"), q.Get("order")
+	if from == "" && to == "" && order == "" {
+		return rng, false, nil
+	}
+
+	if from != "" {
+		rng.From, err = time.Parse(time.RFC3339, from)
+		if err != nil {
+			return rng, false, fmt.Errorf(`Invalid "from" parameter`)
+		}
+	}
+	if to != "" {
+		rng.To, err = time.Parse(time.RFC3339, to)
+		if err != nil {
+			return rng, false, fmt.Errorf(`Invalid "to" parameter`)
+		}
+	}
+
+	switch strings.ToLower(order) {
+	case "", "desc":
+	case "asc":
+		rng.Order = history.Ascending
+	default:
+		return rng, false, fmt.Errorf(`Invalid "order" parameter`)
+	}
+
+	if rng.Validate() != nil {
+		return rng, false, fmt.Errorf(`Invalid range: "to" must be after "from"`)
+	}
+
+	return rng, true, nil
+}
+
+// symbolPattern matches a valid stock symbol, as the routes in newMux do.
+var symbolPattern = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+
+// parseSymbols returns the unique, lowercase symbols in the request's
+// comma-separated "symbols" query parameter, or nil if the request doesn't
+// include it.
+func parseSymbols(r *http.Request) ([]string, error) {
+	param := r.URL.Query().Get("symbols")
+	if param == "" {
+		return nil, nil
+	}
+
+	var (
+		symbols []string
+		seen    = make(map[string]struct{})
+	)
+	for _, symbol := range strings.Split(param, ",") {
+		symbol = strings.TrimSpace(symbol)
+		if !symbolPattern.MatchString(symbol) {
+			return nil, fmt.Errorf(`Invalid "symbols" parameter`)
+		}
+		symbol = strings.ToLower(symbol)
+		if _, ok := seen[symbol]; ok {
+			continue
+		}
+		seen[symbol] = struct{}{}
+		symbols = append(symbols, symbol)
+	}
+
+	return symbols, nil
+}
+
+func candles(p history.Provider, v view, log *zap.SugaredLogger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var (
-			err  error
-			last int
+			err      error
+			interval time.Duration
+			last     int
 		)
 		_, _ = io.Copy(io.Discard, r.Body)
 		_ = r.Body.Close()
 
+		cp, ok := p.(history.CandleProvider)
+		if !ok {
+			w.WriteHeader(http.StatusNotImplemented)
+			_, _ = w.Write([]byte("Candles not supported by storage backend"))
+			return
+		}
+
 		vars := mux.Vars(r)
 		symbol, ok := vars["symbol"]
 		if !ok || symbol == "" {
@@ -31,6 +132,13 @@ func stock(p history.Provider, log *zap.SugaredLogger) http.HandlerFunc {
 			return
 		}
 
+		interval, err = time.ParseDuration(r.URL.Query().Get("interval"))
+		if err != nil || history.ValidateInterval(interval) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`Invalid "interval" parameter`))
+			return
+		}
+
 		if l := r.URL.Query().Get("last"); l != "" {
 			last, err = strconv.Atoi(l)
 			if err != nil {
@@ -40,7 +148,14 @@ func stock(p history.Provider, log *zap.SugaredLogger) http.HandlerFunc {
 			}
 		}
 
-		quotes, err := p.GetQuotes(r.Context(), strings.ToLower(symbol), last)
+		rng, _, err := parseRange(r, last)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(err.Error()))
+			return
+		}
+
+		c, err := cp.GetCandles(r.Context(), strings.ToLower(symbol), interval, rng)
 		if err != nil {
 			if err == history.ErrNotFound {
 				w.WriteHeader(http.StatusNotFound)
@@ -54,14 +169,14 @@ func stock(p history.Provider, log *zap.SugaredLogger) http.HandlerFunc {
 		}
 
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
-		err = json.NewEncoder(w).Encode(quotes)
+		err = json.NewEncoder(w).Encode(v(c))
 		if err != nil {
 			log.Warn(err)
 		}
 	}
 }
 
-func stocks(p history.Provider, log *zap.SugaredLogger) http.HandlerFunc {
+func stock(p history.Provider, v view, log *zap.SugaredLogger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var (
 			err  error
@@ -70,6 +185,15 @@ func stocks(p history.Provider, log *zap.SugaredLogger) http.HandlerFunc {
 		_, _ = io.Copy(io.Discard, r.Body)
 		_ = r.Body.Close()
 
+		vars := mux.Vars(r)
+		symbol, ok := vars["symbol"]
+		if !ok || symbol == "" {
+			log.Errorw("symbol not found in request URI!", "uri", r.RequestURI)
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("Internal server error"))
+			return
+		}
+
 		if l := r.URL.Query().Get("last"); l != "" {
 			last, err = strconv.Atoi(l)
 			if err != nil {
@@ -79,7 +203,19 @@ func stocks(p history.Provider, log *zap.SugaredLogger) http.HandlerFunc {
 			}
 		}
 
-		batch, err := p.GetQuotesBatch(r.Context(), finance.DefaultSymbols, last)
+		rng, ok, err := parseRange(r, last)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(err.Error()))
+			return
+		}
+
+		var quotes []finance.Quote
+		if ok {
+			quotes, err = p.GetQuotesRange(r.Context(), strings.ToLower(symbol), rng)
+		} else {
+			quotes, err = p.GetQuotes(r.Context(), strings.ToLower(symbol), last)
+		}
 		if err != nil {
 			if err == history.ErrNotFound {
 				w.WriteHeader(http.StatusNotFound)
@@ -93,9 +229,120 @@ func stocks(p history.Provider, log *zap.SugaredLogger) http.HandlerFunc {
 		}
 
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
-		err = json.NewEncoder(w).Encode(batch)
+		err = json.NewEncoder(w).Encode(v(quotes))
 		if err != nil {
 			log.Warn(err)
 		}
 	}
 }
+
+// stocks returns the quotes of the requested symbols, or of every tracked
+// symbol if the request doesn't name any. Requested symbols that aren't
+// tracked are reported in a partialBatch alongside the quotes of the rest,
+// with a 207 Multi-Status code, or a 404 if none of the symbols are tracked.
+func stocks(p history.Provider,
+	tracked func(context.Context) ([]string, error), v view,
+	log *zap.SugaredLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			err  error
+			last int
+		)
+		_, _ = io.Copy(io.Discard, r.Body)
+		_ = r.Body.Close()
+
+		if l := r.URL.Query().Get("last"); l != "" {
+			last, err = strconv.Atoi(l)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`Invalid "last" parameter`))
+				return
+			}
+		}
+
+		rng, ok, err := parseRange(r, last)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(err.Error()))
+			return
+		}
+
+		requested, err := parseSymbols(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(err.Error()))
+			return
+		}
+
+		symbols, err := tracked(r.Context())
+		if err != nil {
+			log.Error(err, zap.String("url", r.URL.String()))
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("Internal server error"))
+			return
+		}
+
+		resp := partialBatch{Errors: make(map[string]symbolError)}
+		if requested != nil {
+			trackedSet := make(map[string]struct{}, len(symbols))
+			for _, symbol := range symbols {
+				trackedSet[symbol] = struct{}{}
+			}
+
+			symbols = nil
+			for _, symbol := range requested {
+				if _, ok := trackedSet[symbol]; ok {
+					symbols = append(symbols, symbol)
+					continue
+				}
+				resp.Errors[symbol] = symbolError{
+					Status: http.StatusNotFound,
+					Error:  "Unknown symbol",
+				}
+			}
+		}
+
+		if len(symbols) == 0 {
+			writeJSON(w, http.StatusNotFound, v(resp), log)
+			return
+		}
+
+		if ok {
+			resp.Quotes, err = p.GetQuotesBatchRange(r.Context(), symbols, rng)
+		} else {
+			resp.Quotes, err = p.GetQuotesBatch(r.Context(), symbols, last)
+		}
+		if err != nil {
+			switch {
+			case err == history.ErrNotFound && len(resp.Errors) > 0:
+				writeJSON(w, http.StatusNotFound, v(resp), log)
+			case err == history.ErrNotFound:
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte("Not found"))
+			default:
+				log.Error(err, zap.String("url", r.URL.String()))
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte("Internal server error"))
+			}
+			return
+		}
+
+		if len(resp.Errors) > 0 {
+			writeJSON(w, http.StatusMultiStatus, v(resp), log)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, v(resp.Quotes), log)
+	}
+}
+
+// writeJSON writes v to w as a JSON response body with the given status code.
+func writeJSON(w http.ResponseWriter, code int, v interface{},
+	log *zap.SugaredLogger) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Warn(err)
+	}
+}
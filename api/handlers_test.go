@@ -5,17 +5,23 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/awoodbeck/faang-stonks/history"
 	"github.com/awoodbeck/faang-stonks/history/memory"
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 )
 
+// dec parses a Decimal constant, such as a price.
+var dec = finance.MustParseDecimal
+
 var (
-	provider = memory.New()
+	provider *memory.Client
 	log      *zap.SugaredLogger
 	router   *mux.Router
 )
@@ -46,8 +52,8 @@ func TestStockHandler(t *testing.T) {
 	}
 
 	expected := []finance.Quote{
-		{Price: 123.40, Symbol: "fb", Time: time.Now().Add(time.Hour)},
-		{Price: 123.42, Symbol: "fb", Time: time.Now().Add(time.Minute)},
+		{Price: dec("123.40"), Symbol: "fb", Time: time.Now().Add(time.Hour)},
+		{Price: dec("123.42"), Symbol: "fb", Time: time.Now().Add(time.Minute)},
 	}
 
 	if len(actual) != len(expected) {
@@ -59,7 +65,7 @@ func TestStockHandler(t *testing.T) {
 
 	for i, q := range actual {
 		if q.Price != expected[i].Price {
-			t.Errorf("actual price: %.2f; expected: %.2f", q.Price,
+			t.Errorf("actual price: %s; expected: %s", q.Price,
 				expected[i].Price)
 		}
 		if q.Symbol != expected[i].Symbol {
@@ -69,6 +75,101 @@ func TestStockHandler(t *testing.T) {
 	}
 }
 
+func TestStockHandlerRange(t *testing.T) {
+	t.Parallel()
+
+	for _, uri := range []string{
+		"/v1/stock/fb?from=blah",
+		"/v1/stock/fb?to=blah",
+		"/v1/stock/fb?order=blah",
+		"/v1/stock/fb?from=2021-05-07T19:00:00Z&to=2021-05-07T18:00:00Z",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, uri, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%q results in code: %q", uri, http.StatusText(w.Code))
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet,
+		"/v1/stock/fb?to=2000-01-01T00:00:00Z", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("empty range results in code: %q", http.StatusText(w.Code))
+	}
+
+	from := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet,
+		"/v1/stock/fb?order=asc&last=2&from="+from, nil))
+
+	var actual []finance.Quote
+	err := json.NewDecoder(w.Body).Decode(&actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []finance.Quote{
+		{Price: dec("123.45"), Symbol: "fb"},
+		{Price: dec("123.42"), Symbol: "fb"},
+	}
+
+	if len(actual) != len(expected) {
+		t.Error("actual quote count not equal to expected count")
+		t.Logf("expected: %#v", expected)
+		t.Logf("actual:   %#v", actual)
+		t.Skip()
+	}
+
+	for i, q := range actual {
+		if q.Price != expected[i].Price {
+			t.Errorf("actual price: %s; expected: %s", q.Price,
+				expected[i].Price)
+		}
+	}
+}
+
+func TestCandlesHandler(t *testing.T) {
+	t.Parallel()
+
+	for uri, code := range map[string]int{
+		"/v1/stock/fb/candles":               http.StatusBadRequest,
+		"/v1/stock/fb/candles?interval=blah": http.StatusBadRequest,
+		"/v1/stock/fb/candles?interval=1ms":  http.StatusBadRequest,
+		"/v1/stock/blah/candles?interval=5m": http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, uri, nil))
+		if w.Code != code {
+			t.Errorf("%q results in code: %q", uri, http.StatusText(w.Code))
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet,
+		"/v1/stock/goog/candles?interval=24h", nil))
+
+	var actual []history.Candle
+	err := json.NewDecoder(w.Body).Decode(&actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	for _, c := range actual {
+		count += c.Count
+		if c.Symbol != "goog" {
+			t.Errorf("actual symbol: %q; expected: %q", c.Symbol, "goog")
+		}
+		if c.High < c.Low {
+			t.Errorf("high %s is less than low %s", c.High, c.Low)
+		}
+	}
+	if count != 2 {
+		t.Errorf("actual quote count: %d; expected: 2", count)
+	}
+}
+
 func TestStocksHandler(t *testing.T) {
 	t.Parallel()
 
@@ -89,12 +190,12 @@ func TestStocksHandler(t *testing.T) {
 
 	expected := finance.QuoteBatch{
 		"fb": {
-			{Price: 123.40, Symbol: "fb"},
-			{Price: 123.42, Symbol: "fb"},
+			{Price: dec("123.40"), Symbol: "fb"},
+			{Price: dec("123.42"), Symbol: "fb"},
 		},
 		"goog": {
-			{Price: 234.51, Symbol: "goog"},
-			{Price: 234.56, Symbol: "goog"},
+			{Price: dec("234.51"), Symbol: "goog"},
+			{Price: dec("234.56"), Symbol: "goog"},
 		},
 	}
 
@@ -108,7 +209,7 @@ func TestStocksHandler(t *testing.T) {
 	for symbol := range actual {
 		for i, q := range actual[symbol] {
 			if q.Price != expected[symbol][i].Price {
-				t.Errorf("actual price: %.2f; expected: %.2f", q.Price,
+				t.Errorf("actual price: %s; expected: %s", q.Price,
 					expected[symbol][i].Price)
 			}
 			if q.Symbol != expected[symbol][i].Symbol {
@@ -119,18 +220,84 @@ func TestStocksHandler(t *testing.T) {
 	}
 }
 
+func TestStocksHandlerSymbols(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/stocks?symbols=fb,go*g", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bad 'symbols' parameter results in code: %q", http.StatusText(w.Code))
+	}
+
+	for _, tc := range []struct {
+		uri     string
+		code    int
+		quotes  []string
+		unknown []string
+	}{
+		{uri: "/v1/stocks?symbols=GOOG", code: http.StatusOK, quotes: []string{"goog"}},
+		{uri: "/v1/stocks?symbols=goog,tsla", code: http.StatusMultiStatus,
+			quotes: []string{"goog"}, unknown: []string{"tsla"}},
+		{uri: "/v1/stocks?symbols=tsla,gme&last=1", code: http.StatusNotFound,
+			unknown: []string{"gme", "tsla"}},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.uri, nil))
+		if w.Code != tc.code {
+			t.Errorf("%q results in code: %q", tc.uri, http.StatusText(w.Code))
+			continue
+		}
+
+		var actual partialBatch
+		if tc.code == http.StatusOK {
+			err := json.NewDecoder(w.Body).Decode(&actual.Quotes)
+			if err != nil {
+				t.Fatal(err)
+			}
+		} else if err := json.NewDecoder(w.Body).Decode(&actual); err != nil {
+			t.Fatal(err)
+		}
+
+		var quotes, unknown []string
+		for symbol := range actual.Quotes {
+			quotes = append(quotes, symbol)
+		}
+		for symbol, e := range actual.Errors {
+			unknown = append(unknown, symbol)
+			if e.Status != http.StatusNotFound {
+				t.Errorf("%q: %q has status %d", tc.uri, symbol, e.Status)
+			}
+		}
+		sort.Strings(unknown)
+
+		if !reflect.DeepEqual(quotes, tc.quotes) {
+			t.Errorf("%q: actual quotes: %v; expected: %v", tc.uri, quotes, tc.quotes)
+		}
+		if !reflect.DeepEqual(unknown, tc.unknown) {
+			t.Errorf("%q: actual unknown: %v; expected: %v", tc.uri, unknown, tc.unknown)
+		}
+	}
+}
+
 func init() {
 	log = zap.NewExample().Sugar()
+
+	var err error
+	provider, err = memory.New()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	quotes := []finance.Quote{
-		{Price: 123.45, Symbol: "fb", Time: time.Now()},
-		{Price: 123.42, Symbol: "fb", Time: time.Now().Add(time.Minute)},
-		{Price: 123.40, Symbol: "fb", Time: time.Now().Add(time.Hour)},
-		{Price: 234.56, Symbol: "goog", Time: time.Now()},
-		{Price: 234.51, Symbol: "goog", Time: time.Now().Add(time.Minute)},
+		{Price: dec("123.45"), Symbol: "fb", Time: time.Now()},
+		{Price: dec("123.42"), Symbol: "fb", Time: time.Now().Add(time.Minute)},
+		{Price: dec("123.40"), Symbol: "fb", Time: time.Now().Add(time.Hour)},
+		{Price: dec("234.56"), Symbol: "goog", Time: time.Now()},
+		{Price: dec("234.51"), Symbol: "goog", Time: time.Now().Add(time.Minute)},
 	}
 
 	if err := provider.SetQuotes(context.Background(), quotes); err != nil {
 		log.Fatal(err)
 	}
-	router = newMux(provider, log, false)
+	router = (&Server{log: log, symbols: finance.DefaultSymbols}).newMux(provider)
 }
@@ -12,22 +12,52 @@ import (
 	"go.uber.org/zap"
 )
 
-// newMux returns a new Gorilla mux.
-func newMux(provider history.Provider, log *zap.SugaredLogger,
-	instrument bool) *mux.Router {
-	log = log.Named("mux")
+// newMux returns a new Gorilla mux serving the server's routes.
+func (s *Server) newMux(provider history.Provider) *mux.Router {
+	log := s.log.Named("mux")
 
 	r := mux.NewRouter().StrictSlash(true)
-	r.Use(gziphandler.GzipHandler, zapLoggerMiddleware(log))
+	r.Use(zapLoggerMiddleware(log))
 
-	if instrument {
+	if s.instrumentation {
 		r.Use(metricsMiddleware)
 		log.Info("API instrumented")
 	}
 
-	s := r.Methods("GET").PathPrefix("/v1").Subrouter()
-	s.HandleFunc("/stocks", stocks(provider, log))
-	s.HandleFunc("/stock/{symbol:[a-zA-Z0-9]+}", stock(provider, log))
+	v := plain
+	if s.decimalStrings {
+		v = decimalStrings
+	}
+
+	// Streamed quotes aren't compressed, since the gzip handler would buffer
+	// them.
+	if s.hub != nil {
+		var done <-chan struct{}
+		if s.ctx != nil {
+			done = s.ctx.Done()
+		}
+		r.Methods("GET").Path("/v1/stream").HandlerFunc(
+			stream(provider, s.hub, s.streamHeartbeat, v, done, log))
+		r.Methods("GET").Path("/v1/ws").HandlerFunc(
+			webSocket(s.hub, s.wsMaxSubscriptions, s.wsPingInterval,
+				s.wsWriteTimeout, v, done, log))
+	}
+
+	if s.registry != nil && s.adminToken != "" {
+		admin := r.PathPrefix("/v1/admin").Subrouter()
+		admin.Use(bearerAuth(s.adminToken))
+		admin.Methods("GET").Path("/symbols").HandlerFunc(
+			adminSymbols(s.registry, log))
+		admin.Methods("POST", "DELETE").Path("/symbols/{symbol:[a-zA-Z0-9]+}").
+			HandlerFunc(adminSymbols(s.registry, log))
+		log.Info("admin API enabled")
+	}
+
+	v1 := r.Methods("GET").PathPrefix("/v1").Subrouter()
+	v1.Use(gziphandler.GzipHandler)
+	v1.HandleFunc("/stocks", stocks(provider, s.trackedSymbols, v, log))
+	v1.HandleFunc("/stock/{symbol:[a-zA-Z0-9]+}", stock(provider, v, log))
+	v1.HandleFunc("/stock/{symbol:[a-zA-Z0-9]+}/candles", candles(provider, v, log))
 
 	return r
 }
@@ -1,9 +1,32 @@
 package api
 
-import "time"
+import (
+	"strings"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/history"
+	"github.com/awoodbeck/faang-stonks/pubsub"
+)
 
 type Option func(*Server)
 
+// AdminToken enables the admin API, which manages the registry's symbols, for
+// requests bearing the given token. The admin API requires a Registry.
+func AdminToken(token string) Option {
+	return func(s *Server) {
+		s.adminToken = token
+	}
+}
+
+// DecimalStrings encodes prices in responses as JSON strings (e.g., "123.42")
+// rather than numbers, for clients that would otherwise decode them to binary
+// floating point.
+func DecimalStrings() Option {
+	return func(s *Server) {
+		s.decimalStrings = true
+	}
+}
+
 // DisableInstrumentation turns off server instrumentation.
 func DisableInstrumentation() Option {
 	return func(s *Server) {
@@ -38,3 +61,65 @@ func ReadHeaderTimeout(d time.Duration) Option {
 		}
 	}
 }
+
+// Registry tracks the symbols registered with the given registry, which
+// GET /v1/stocks reads on each request, rather than the Symbols setting.
+func Registry(r history.SymbolRegistry) Option {
+	return func(s *Server) {
+		s.registry = r
+	}
+}
+
+// Stream enables GET /v1/stream and GET /v1/ws, which push the quotes
+// published to the given hub to clients as server-sent events and WebSocket
+// frames, respectively.
+func Stream(h *pubsub.Hub) Option {
+	return func(s *Server) {
+		s.hub = h
+	}
+}
+
+// StreamHeartbeat sets the duration between heartbeats sent to idle stream
+// clients to keep proxies from closing their connections.
+func StreamHeartbeat(d time.Duration) Option {
+	return func(s *Server) {
+		if d > 0 {
+			s.streamHeartbeat = d
+		}
+	}
+}
+
+// Symbols sets the tracked stock symbols, which GET /v1/stocks returns by
+// default and limits requested symbols to.
+func Symbols(symbols []string) Option {
+	return func(s *Server) {
+		if len(symbols) == 0 {
+			return
+		}
+		s.symbols = make([]string, len(symbols))
+		for i, symbol := range symbols {
+			s.symbols[i] = strings.ToLower(symbol)
+		}
+	}
+}
+
+// WebSocketMaxSubscriptions sets the maximum number of symbols a WebSocket
+// client may subscribe to.
+func WebSocketMaxSubscriptions(n int) Option {
+	return func(s *Server) {
+		if n > 0 {
+			s.wsMaxSubscriptions = n
+		}
+	}
+}
+
+// WebSocketPingInterval sets the duration between pings sent to WebSocket
+// clients. Clients that don't answer a ping before the next one are
+// disconnected.
+func WebSocketPingInterval(d time.Duration) Option {
+	return func(s *Server) {
+		if d > 0 {
+			s.wsPingInterval = d
+		}
+	}
+}
@@ -11,7 +11,9 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/awoodbeck/faang-stonks/finance"
 	"github.com/awoodbeck/faang-stonks/history"
+	"github.com/awoodbeck/faang-stonks/pubsub"
 	"go.uber.org/zap"
 )
 
@@ -27,6 +29,18 @@ const (
 	// DefaultReadHeaderTimeout is the default duration during which the API
 	// server will wait for the client to send request headers.
 	DefaultReadHeaderTimeout = 30 * time.Second
+
+	// DefaultStreamHeartbeat is the default duration between heartbeats sent
+	// to idle stream clients.
+	DefaultStreamHeartbeat = 15 * time.Second
+
+	// DefaultWebSocketMaxSubscriptions is the default maximum number of
+	// symbols a WebSocket client may subscribe to.
+	DefaultWebSocketMaxSubscriptions = 50
+
+	// DefaultWebSocketPingInterval is the default duration between pings
+	// sent to WebSocket clients.
+	DefaultWebSocketPingInterval = 30 * time.Second
 )
 
 // Server is a web server that serves up the REST API for this application.
@@ -40,6 +54,16 @@ type Server struct {
 	idleTimeout       time.Duration
 	readHeaderTimeout time.Duration
 	instrumentation   bool
+	decimalStrings    bool
+	symbols           []string
+	registry          history.SymbolRegistry
+	adminToken        string
+	hub               *pubsub.Hub
+	streamHeartbeat   time.Duration
+
+	wsMaxSubscriptions int
+	wsPingInterval     time.Duration
+	wsWriteTimeout     time.Duration
 }
 
 // ListenAndServe binds the server to its address and serves incoming requests.
@@ -75,12 +99,29 @@ func (s *Server) ListenAndServeTLS(cert, pkey string) error {
 	return s.srv.ListenAndServeTLS(cert, pkey)
 }
 
+// trackedSymbols returns the registered symbols if the server has a registry,
+// or the configured symbols otherwise.
+func (s *Server) trackedSymbols(ctx context.Context) ([]string, error) {
+	if s.registry != nil {
+		return s.registry.Symbols(ctx)
+	}
+
+	return s.symbols, nil
+}
+
 // New returns a pointer to an API Server.
 //
 // Defaults:
-//     IdleTimeout       = time.Minute
-//     ListenAddress     = ":18081"
-//     ReadHeaderTimeout = 30 * time.Second
+//     AdminToken                = "" (admin API disabled)
+//     IdleTimeout               = time.Minute
+//     ListenAddress             = ":18081"
+//     ReadHeaderTimeout         = 30 * time.Second
+//     Registry                  = nil (Symbols are tracked)
+//     Stream                    = nil (GET /v1/stream and /v1/ws disabled)
+//     StreamHeartbeat           = 15 * time.Second
+//     Symbols                   = default symbols from finance package
+//     WebSocketMaxSubscriptions = 50
+//     WebSocketPingInterval     = 30 * time.Second
 func New(ctx context.Context, p history.Provider, log *zap.SugaredLogger,
 	options ...Option) (
 	*Server, error) {
@@ -91,6 +132,12 @@ func New(ctx context.Context, p history.Provider, log *zap.SugaredLogger,
 		idleTimeout:       DefaultIdleTimeout,
 		readHeaderTimeout: DefaultReadHeaderTimeout,
 		instrumentation:   true,
+		symbols:           finance.DefaultSymbols,
+		streamHeartbeat:   DefaultStreamHeartbeat,
+
+		wsMaxSubscriptions: DefaultWebSocketMaxSubscriptions,
+		wsPingInterval:     DefaultWebSocketPingInterval,
+		wsWriteTimeout:     wsWriteTimeout,
 	}
 
 	for _, option := range options {
@@ -99,11 +146,15 @@ func New(ctx context.Context, p history.Provider, log *zap.SugaredLogger,
 		}
 	}
 
+	if s.adminToken != "" && s.registry == nil {
+		s.log.Warn("admin API disabled: storage has no symbol registry")
+	}
+
 	s.srv = &http.Server{
 		Addr:              s.listenAddr,
 		IdleTimeout:       s.idleTimeout,
 		ReadHeaderTimeout: s.readHeaderTimeout,
-		Handler:           newMux(p, log, s.instrumentation),
+		Handler:           s.newMux(p),
 	}
 
 	return s, nil
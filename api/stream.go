@@ -0,0 +1,204 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/awoodbeck/faang-stonks/history"
+	"github.com/awoodbeck/faang-stonks/pubsub"
+	"go.uber.org/zap"
+)
+
+// stream pushes each newly archived quote to the client as a server-sent
+// event. Each event's ID is the hub's sequence number for the quote followed
+// by the quote's time in nanoseconds since the Unix epoch (e.g.,
+// "1620415867300000042-1620415867272000000"). A reconnecting client that
+// sends the Last-Event-ID header first receives the quotes it missed, in the
+// order they were published, from the hub's backlog. If the backlog no longer
+// holds them, as after a restart, the client receives the archived quotes
+// from the last quote's time on instead, which may repeat quotes it already
+// has. Comments sent every heartbeat keep proxies from closing idle
+// connections.
+func stream(p history.Provider, hub *pubsub.Hub, heartbeat time.Duration,
+	v view, done <-chan struct{}, log *zap.SugaredLogger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		_ = r.Body.Close()
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			log.Error("response writer does not support flushing")
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("Internal server error"))
+			return
+		}
+
+		symbols, err := parseSymbols(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(err.Error()))
+			return
+		}
+
+		var (
+			lastSeq  uint64
+			lastTime time.Time
+		)
+		id := r.Header.Get("Last-Event-ID")
+		if id != "" {
+			lastSeq, lastTime, err = parseEventID(id)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte("Invalid Last-Event-ID header"))
+				return
+			}
+		}
+
+		// Subscribe before reading history so no quote archived in between
+		// is missed.
+		var sub *pubsub.Subscription
+		if len(symbols) > 0 {
+			sub = hub.Subscribe(symbols...)
+		} else {
+			sub = hub.SubscribeAll()
+		}
+		defer sub.Close()
+
+		// The backlog may include quotes published since subscribing, which
+		// are skipped when they arrive on the subscription.
+		var (
+			events  []pubsub.Event
+			missed  []finance.Quote
+			resumed bool
+		)
+		if id != "" {
+			events, resumed = hub.Since(lastSeq, symbols...)
+		}
+		if id != "" && !resumed {
+			batch, err := p.GetQuotesBatchRange(r.Context(), symbols,
+				history.Range{
+					From:  lastTime,
+					Order: history.Ascending,
+				})
+			if err != nil && !errors.Is(err, history.ErrNotFound) {
+				log.Error(err, zap.String("url", r.URL.String()))
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte("Internal server error"))
+				return
+			}
+			for _, quotes := range batch {
+				missed = append(missed, quotes...)
+			}
+			sort.SliceStable(missed, func(i, j int) bool {
+				return missed[i].Time.Before(missed[j].Time)
+			})
+		}
+
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		w.Header().Set("X-Accel-Buffering", "no") // disable Nginx buffering
+
+		for _, e := range events {
+			if err = writeEvent(w, e, v); err != nil {
+				return
+			}
+			lastSeq = e.Seq
+		}
+
+		// Archived quotes carry no sequence number, so they're sent without
+		// an ID, leaving the client's last event ID as it was. replayed
+		// tracks the latest quote replayed for each symbol, so quotes that
+		// were also published while replaying aren't sent twice.
+		replayed := make(map[string]time.Time)
+		for _, q := range missed {
+			if err = writeEvent(w, pubsub.Event{Quote: q}, v); err != nil {
+				return
+			}
+			replayed[strings.ToLower(q.Symbol)] = q.Time
+		}
+		flusher.Flush()
+
+		t := time.NewTicker(heartbeat)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-done:
+				return
+			case e, ok := <-sub.C():
+				if !ok {
+					return
+				}
+				if resumed && e.Seq <= lastSeq {
+					continue
+				}
+				last, ok := replayed[strings.ToLower(e.Quote.Symbol)]
+				if ok && !e.Quote.Time.After(last) {
+					continue
+				}
+				err = writeEvent(w, e, v)
+			case <-t.C:
+				_, err = io.WriteString(w, ": heartbeat\n\n")
+			}
+			if err != nil {
+				log.Debugf("stream to %s: %v", r.RemoteAddr, err)
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent writes the event's quote to w as a server-sent event, with an
+// ID unless the event has no sequence number.
+func writeEvent(w io.Writer, e pubsub.Event, v view) error {
+	b, err := json.Marshal(v(e.Quote))
+	if err != nil {
+		return err
+	}
+
+	if e.Seq > 0 {
+		_, err = fmt.Fprintf(w, "id: %d-%d\n", e.Seq, e.Quote.Time.UnixNano())
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: quote\ndata: %s\n\n", b)
+
+	return err
+}
+
+// parseEventID parses an event ID written by writeEvent into the hub's
+// sequence number and the quote's time.
+func parseEventID(id string) (uint64, time.Time, error) {
+	i := strings.Index(id, "-")
+	if i < 0 {
+		return 0, time.Time{}, fmt.Errorf("event ID %q has no sequence number", id)
+	}
+
+	seq, err := strconv.ParseUint(id[:i], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if seq == 0 {
+		return 0, time.Time{}, fmt.Errorf("event ID %q has no sequence number", id)
+	}
+
+	n, err := strconv.ParseInt(id[i+1:], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	return seq, time.Unix(0, n), nil
+}
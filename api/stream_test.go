@@ -0,0 +1,211 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/awoodbeck/faang-stonks/history"
+	"github.com/awoodbeck/faang-stonks/pubsub"
+)
+
+// readEvent returns the ID, type, and data of the next server-sent event,
+// or "heartbeat" as the type of a heartbeat comment.
+func readEvent(t *testing.T, r *bufio.Reader) (id, event, data string) {
+	t.Helper()
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "":
+			if event != "" {
+				return id, event, data
+			}
+		case line == ": heartbeat":
+			event = "heartbeat"
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestStreamHandler(t *testing.T) {
+	t.Parallel()
+
+	hub := pubsub.New()
+	s := &Server{log: log, hub: hub, streamHeartbeat: 50 * time.Millisecond}
+	srv := httptest.NewServer(s.newMux(provider))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/v1/stream?symbols=fb,go*g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("bad 'symbols' parameter results in code: %q", resp.Status)
+	}
+
+	// Resume from the oldest fb quote with an ID the hub didn't issue, which
+	// replays the archived quotes from that quote's time on. An ID without a
+	// sequence number is invalid.
+	oldest, err := provider.GetQuotesRange(context.Background(), "fb",
+		history.Range{Limit: 1, Order: history.Ascending})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nanos := strconv.FormatInt(oldest[0].Time.UnixNano(), 10)
+	for _, lastID := range []string{nanos, "0-" + nanos} {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/v1/stream?symbols=FB", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Last-Event-ID", lastID)
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Last-Event-ID %q results in code: %q", lastID, resp.Status)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/v1/stream?symbols=FB", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Last-Event-ID", "1-"+nanos)
+
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Errorf("actual content type: %q", ct)
+	}
+	r := bufio.NewReader(resp.Body)
+
+	var last finance.Quote
+	for _, expected := range []finance.Decimal{oldest[0].Price, dec("123.42"),
+		dec("123.40")} {
+		id, event, data := readEvent(t, r)
+		if event != "quote" {
+			t.Fatalf("actual event: %q; expected: %q", event, "quote")
+		}
+		if err = json.Unmarshal([]byte(data), &last); err != nil {
+			t.Fatal(err)
+		}
+		if last.Price != expected {
+			t.Errorf("actual price: %s; expected: %s", last.Price, expected)
+		}
+		if id != "" {
+			t.Errorf("archived quote sent with event ID %q", id)
+		}
+	}
+
+	// The replayed quote isn't sent twice, nor is another symbol's quote.
+	hub.Publish([]finance.Quote{
+		last,
+		{Price: dec("234.50"), Symbol: "goog", Time: last.Time.Add(time.Second)},
+		{Price: dec("123.39"), Symbol: "fb", Time: last.Time.Add(time.Second)},
+	})
+
+	id, event, data := readEvent(t, r)
+	if event != "quote" || !strings.Contains(data, `"price":123.39`) {
+		t.Errorf("actual event: %q; data: %s", event, data)
+	}
+	if _, _, err = parseEventID(id); err != nil || !strings.Contains(id, "-") {
+		t.Errorf("invalid event ID %q: %v", id, err)
+	}
+
+	if _, event, _ = readEvent(t, r); event != "heartbeat" {
+		t.Errorf("actual event: %q; expected: %q", event, "heartbeat")
+	}
+}
+
+func TestStreamResume(t *testing.T) {
+	t.Parallel()
+
+	hub := pubsub.New()
+	s := &Server{log: log, hub: hub, streamHeartbeat: time.Minute}
+	srv := httptest.NewServer(s.newMux(provider))
+	defer srv.Close()
+
+	connect := func(lastID string) (*bufio.Reader, func()) {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodGet,
+			srv.URL+"/v1/stream?symbols=aapl,amzn,nflx", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lastID != "" {
+			req.Header.Set("Last-Event-ID", lastID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return bufio.NewReader(resp.Body), func() { _ = resp.Body.Close() }
+	}
+
+	r, disconnect := connect("")
+
+	// Quotes of several symbols share timestamps or arrive out of order.
+	now := time.Now().UTC()
+	quotes := []finance.Quote{
+		{Price: dec("1"), Symbol: "aapl", Time: now},
+		{Price: dec("2"), Symbol: "amzn", Time: now},
+		{Price: dec("3"), Symbol: "nflx", Time: now.Add(-time.Minute)},
+		{Price: dec("4"), Symbol: "aapl", Time: now.Add(time.Second)},
+		{Price: dec("5"), Symbol: "nflx", Time: now},
+	}
+	hub.Publish(quotes[:3])
+
+	// The client disconnects after the first quote, missing the rest.
+	id, _, _ := readEvent(t, r)
+	disconnect()
+	hub.Publish(quotes[3:])
+
+	r, disconnect = connect(id)
+	defer disconnect()
+
+	for _, expected := range quotes[1:] {
+		_, event, data := readEvent(t, r)
+		var q finance.Quote
+		if err := json.Unmarshal([]byte(data), &q); err != nil {
+			t.Fatal(err)
+		}
+		if event != "quote" || q.Price != expected.Price {
+			t.Errorf("actual event: %q; quote: %#v; expected: %#v", event, q,
+				expected)
+		}
+	}
+
+	// Live quotes follow without repeating the replayed ones.
+	hub.Publish([]finance.Quote{{Price: dec("6"), Symbol: "amzn", Time: now}})
+	_, _, data := readEvent(t, r)
+	if !strings.Contains(data, `"price":6`) {
+		t.Errorf("actual data: %s", data)
+	}
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/awoodbeck/faang-stonks/pubsub"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	// wsMaxMessageSize is the largest message, in bytes, read from a
+	// WebSocket client.
+	wsMaxMessageSize = 4096
+
+	// wsWriteTimeout is the default duration allowed to write a frame to a
+	// WebSocket client.
+	wsWriteTimeout = 10 * time.Second
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// wsRequest is a message from a WebSocket client, such as:
+//     {"action": "subscribe", "symbols": ["fb", "goog"]}
+type wsRequest struct {
+	Action  string   `json:"action"`
+	Symbols []string `json:"symbols"`
+}
+
+// wsError is a frame describing why the server rejected a client's message.
+type wsError struct {
+	Type  string `json:"type"` // always "error"
+	Error string `json:"error"`
+}
+
+// wsQuote is a frame holding a newly archived quote.
+type wsQuote struct {
+	Type  string        `json:"type"` // always "quote"
+	Quote finance.Quote `json:"quote"`
+}
+
+// wsSubscriptions is a frame listing the client's subscribed symbols after a
+// subscribe or unsubscribe message.
+type wsSubscriptions struct {
+	Type    string   `json:"type"` // always "subscriptions"
+	Symbols []string `json:"symbols"`
+}
+
+// wsSession tracks a WebSocket client's subscribed symbols. Only the
+// goroutine reading the client's messages uses it.
+type wsSession struct {
+	sub     *pubsub.Subscription
+	symbols map[string]struct{}
+	max     int
+}
+
+// handle applies the client's message to its subscription and returns the
+// frame to send in reply.
+func (s *wsSession) handle(b []byte) interface{} {
+	var req wsRequest
+	if err := json.Unmarshal(b, &req); err != nil {
+		return wsError{Type: "error", Error: "Invalid message"}
+	}
+
+	if len(req.Symbols) == 0 {
+		return wsError{Type: "error", Error: `Missing "symbols"`}
+	}
+	symbols := make([]string, 0, len(req.Symbols))
+	for _, symbol := range req.Symbols {
+		if !symbolPattern.MatchString(symbol) {
+			return wsError{Type: "error",
+				Error: fmt.Sprintf("Invalid symbol %q", symbol)}
+		}
+		symbols = append(symbols, strings.ToLower(symbol))
+	}
+
+	switch req.Action {
+	case "subscribe":
+		added := 0
+		for _, symbol := range symbols {
+			if _, ok := s.symbols[symbol]; !ok {
+				added++
+			}
+		}
+		if len(s.symbols)+added > s.max {
+			return wsError{Type: "error", Error: fmt.Sprintf(
+				"Subscriptions are limited to %d symbols", s.max)}
+		}
+
+		for _, symbol := range symbols {
+			s.symbols[symbol] = struct{}{}
+		}
+		s.sub.Add(symbols...)
+	case "unsubscribe":
+		for _, symbol := range symbols {
+			delete(s.symbols, symbol)
+		}
+		s.sub.Remove(symbols...)
+	default:
+		return wsError{Type: "error",
+			Error: fmt.Sprintf("Unknown action %q", req.Action)}
+	}
+
+	resp := wsSubscriptions{Type: "subscriptions",
+		Symbols: make([]string, 0, len(s.symbols))}
+	for symbol := range s.symbols {
+		resp.Symbols = append(resp.Symbols, symbol)
+	}
+	sort.Strings(resp.Symbols)
+
+	return resp
+}
+
+// webSocket upgrades the connection to a WebSocket, over which the client
+// subscribes to and unsubscribes from symbols, up to maxSubs of them, and
+// receives their quotes as they're archived. The server pings the client
+// every ping interval and closes the connection if the client doesn't
+// answer before the next one. Each frame must be written within the write
+// timeout.
+func webSocket(hub *pubsub.Hub, maxSubs int, ping, write time.Duration,
+	v view, done <-chan struct{}, log *zap.SugaredLogger) http.HandlerFunc {
+	if write <= 0 {
+		write = wsWriteTimeout
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// The upgrader already replied with an HTTP error.
+			log.Debugf("upgrading %s: %v", r.RemoteAddr, err)
+			return
+		}
+		defer func() { _ = conn.Close() }()
+
+		session := &wsSession{
+			sub:     hub.Subscribe(),
+			symbols: make(map[string]struct{}),
+			max:     maxSubs,
+		}
+		defer session.sub.Close()
+
+		// Only this goroutine writes to the connection, so the reader hands
+		// its replies over.
+		var (
+			replies = make(chan interface{})
+			closed  = make(chan struct{})
+		)
+		defer close(closed)
+
+		readErr := make(chan error, 1)
+		go func() {
+			conn.SetReadLimit(wsMaxMessageSize)
+			_ = conn.SetReadDeadline(time.Now().Add(2 * ping))
+			conn.SetPongHandler(func(string) error {
+				return conn.SetReadDeadline(time.Now().Add(2 * ping))
+			})
+
+			for {
+				_, b, err := conn.ReadMessage()
+				if err != nil {
+					readErr <- err
+					return
+				}
+
+				select {
+				case replies <- session.handle(b):
+				case <-closed:
+					return
+				}
+			}
+		}()
+
+		t := time.NewTicker(ping)
+		defer t.Stop()
+
+		// Set the deadline as each frame is written, not before waiting for
+		// one, or an idle connection's deadline passes before its next ping.
+		deadline := func() {
+			_ = conn.SetWriteDeadline(time.Now().Add(write))
+		}
+
+		for {
+			select {
+			case <-done:
+				deadline()
+				_ = conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+				return
+			case err = <-readErr:
+				if !websocket.IsCloseError(err, websocket.CloseNormalClosure,
+					websocket.CloseGoingAway) {
+					log.Debugf("WebSocket %s: %v", r.RemoteAddr, err)
+				}
+				return
+			case reply := <-replies:
+				deadline()
+				err = conn.WriteJSON(v(reply))
+			case e, ok := <-session.sub.C():
+				if !ok {
+					return
+				}
+				deadline()
+				err = conn.WriteJSON(v(wsQuote{Type: "quote", Quote: e.Quote}))
+			case <-t.C:
+				deadline()
+				err = conn.WriteMessage(websocket.PingMessage, nil)
+			}
+			if err != nil {
+				log.Debugf("WebSocket %s: %v", r.RemoteAddr, err)
+				return
+			}
+		}
+	}
+}
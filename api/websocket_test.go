@@ -0,0 +1,158 @@
+package api
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/awoodbeck/faang-stonks/pubsub"
+	"github.com/gorilla/websocket"
+)
+
+// wsFrame holds any frame the server sends a WebSocket client.
+type wsFrame struct {
+	Type    string        `json:"type"`
+	Error   string        `json:"error"`
+	Quote   finance.Quote `json:"quote"`
+	Symbols []string      `json:"symbols"`
+}
+
+func TestWebSocketHandler(t *testing.T) {
+	t.Parallel()
+
+	hub := pubsub.New()
+	s := &Server{log: log, hub: hub, wsMaxSubscriptions: 2,
+		wsPingInterval: 50 * time.Millisecond}
+	srv := httptest.NewServer(s.newMux(provider))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial(
+		"ws"+strings.TrimPrefix(srv.URL, "http")+"/v1/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	pinged := make(chan struct{}, 1)
+	conn.SetPingHandler(func(data string) error {
+		select {
+		case pinged <- struct{}{}:
+		default:
+		}
+		return conn.WriteControl(websocket.PongMessage, []byte(data),
+			time.Now().Add(time.Second))
+	})
+
+	exchange := func(request string) wsFrame {
+		t.Helper()
+
+		err := conn.WriteMessage(websocket.TextMessage, []byte(request))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var f wsFrame
+		if err = conn.ReadJSON(&f); err != nil {
+			t.Fatal(err)
+		}
+
+		return f
+	}
+
+	for _, request := range []string{
+		`{"action":"subscribe"`,
+		`{"action":"subscribe","symbols":[]}`,
+		`{"action":"subscribe","symbols":["go*g"]}`,
+		`{"action":"buy","symbols":["fb"]}`,
+		`{"action":"subscribe","symbols":["fb","goog","nflx"]}`,
+	} {
+		if f := exchange(request); f.Type != "error" || f.Error == "" {
+			t.Errorf("%s results in frame: %#v", request, f)
+		}
+	}
+
+	for _, tc := range []struct {
+		request  string
+		expected []string
+	}{
+		{`{"action":"subscribe","symbols":["FB","nflx"]}`, []string{"fb", "nflx"}},
+		{`{"action":"subscribe","symbols":["fb"]}`, []string{"fb", "nflx"}},
+		{`{"action":"unsubscribe","symbols":["nflx"]}`, []string{"fb"}},
+	} {
+		f := exchange(tc.request)
+		if f.Type != "subscriptions" || !reflect.DeepEqual(f.Symbols, tc.expected) {
+			t.Errorf("%s results in frame: %#v", tc.request, f)
+		}
+	}
+
+	hub.Publish([]finance.Quote{
+		{Price: dec("234.56"), Symbol: "goog"},
+		{Price: dec("123.45"), Symbol: "fb"},
+	})
+
+	var f wsFrame
+	if err = conn.ReadJSON(&f); err != nil {
+		t.Fatal(err)
+	}
+	if f.Type != "quote" || f.Quote.Symbol != "fb" || f.Quote.Price != dec("123.45") {
+		t.Errorf("actual frame: %#v", f)
+	}
+
+	// Pings are handled while reading, so read until one arrives.
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	go func() { _, _, _ = conn.ReadMessage() }()
+
+	select {
+	case <-pinged:
+	case <-time.After(time.Second):
+		t.Error("server did not ping")
+	}
+}
+
+func TestWebSocketIdlePing(t *testing.T) {
+	t.Parallel()
+
+	// An idle client must still be pinged when the ping interval exceeds the
+	// write timeout.
+	s := &Server{log: log, hub: pubsub.New(), wsMaxSubscriptions: 2,
+		wsPingInterval: 200 * time.Millisecond,
+		wsWriteTimeout: 50 * time.Millisecond}
+	srv := httptest.NewServer(s.newMux(provider))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial(
+		"ws"+strings.TrimPrefix(srv.URL, "http")+"/v1/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	pinged := make(chan struct{}, 3)
+	conn.SetPingHandler(func(data string) error {
+		select {
+		case pinged <- struct{}{}:
+		default:
+		}
+		return conn.WriteControl(websocket.PongMessage, []byte(data),
+			time.Now().Add(time.Second))
+	})
+
+	readErr := make(chan error, 1)
+	go func() {
+		_, _, err := conn.ReadMessage()
+		readErr <- err
+	}()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-pinged:
+		case err = <-readErr:
+			t.Fatalf("connection closed before ping %d: %v", i+1, err)
+		case <-time.After(time.Second):
+			t.Fatalf("server did not send ping %d", i+1)
+		}
+	}
+}
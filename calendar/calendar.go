@@ -0,0 +1,251 @@
+// Package calendar provides an exchange calendar that knows when the NYSE and
+// NASDAQ hold their pre-market, regular, and after-hours trading sessions,
+// accounting for weekends, holidays, and early closes.
+//
+// Holidays and early closes are read from a CSV file with a header row naming
+// the date and kind columns, in any order. Dates are formatted YYYY-MM-DD, and
+// kinds are "closed" for holidays and "early" for early closes. Lines
+// beginning with # are comments. A file covering 2021 through 2027 is
+// embedded; supply another with the HolidaysFile option as the exchanges
+// publish their schedules.
+package calendar
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	_ "time/tzdata" // the exchanges' time zone must load on hosts without it
+)
+
+// Timezone is the time zone of the exchanges' sessions.
+const Timezone = "America/New_York"
+
+// Session start times, in minutes after midnight Eastern time.
+const (
+	preMarketOpen        = 4 * 60
+	regularOpen          = 9*60 + 30
+	regularClose         = 16 * 60
+	earlyClose           = 13 * 60
+	afterHoursClose      = 20 * 60
+	earlyAfterHoursClose = 17 * 60
+)
+
+// Kinds of days in a holidays file.
+const (
+	closedDay = "closed"
+	earlyDay  = "early"
+)
+
+var ErrInvalidHolidays = fmt.Errorf("invalid holidays")
+
+//go:embed holidays.csv
+var embeddedHolidays []byte
+
+// Session is a period of the trading day.
+type Session int
+
+const (
+	// Closed covers nights, weekends, and holidays.
+	Closed Session = iota
+
+	// PreMarket runs from 4:00 a.m. until the regular session opens.
+	PreMarket
+
+	// Regular runs from 9:30 a.m. until 4:00 p.m., or 1:00 p.m. on early
+	// closes.
+	Regular
+
+	// AfterHours runs from the regular session's close until 8:00 p.m., or
+	// 5:00 p.m. on early closes.
+	AfterHours
+)
+
+// String returns the session's name.
+func (s Session) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case PreMarket:
+		return "pre-market"
+	case Regular:
+		return "regular"
+	case AfterHours:
+		return "after-hours"
+	default:
+		return fmt.Sprintf("Session(%d)", int(s))
+	}
+}
+
+// Calendar knows the exchanges' trading sessions.
+type Calendar struct {
+	file string
+	loc  *time.Location
+	days map[string]string // kind of each holiday or early close by date
+}
+
+// sessionStart is the time a session begins.
+type sessionStart struct {
+	time    time.Time
+	session Session
+}
+
+// sessions returns the start of each session on the given date, in order.
+// The date is normalized, so its day may fall outside the month.
+func (c *Calendar) sessions(year int, month time.Month, day int) []sessionStart {
+	at := func(minutes int) time.Time {
+		return time.Date(year, month, day, 0, minutes, 0, 0, c.loc)
+	}
+
+	date := at(0)
+	switch date.Weekday() {
+	case time.Saturday, time.Sunday:
+		return nil
+	}
+
+	regularEnd, afterHoursEnd := regularClose, afterHoursClose
+	switch c.days[date.Format("2006-01-02")] {
+	case closedDay:
+		return nil
+	case earlyDay:
+		regularEnd, afterHoursEnd = earlyClose, earlyAfterHoursClose
+	}
+
+	return []sessionStart{
+		{at(preMarketOpen), PreMarket},
+		{at(regularOpen), Regular},
+		{at(regularEnd), AfterHours},
+		{at(afterHoursEnd), Closed},
+	}
+}
+
+// Session returns the session in progress at t.
+func (c *Calendar) Session(t time.Time) Session {
+	t = t.In(c.loc)
+	session := Closed
+
+	for _, s := range c.sessions(t.Date()) {
+		if !t.Before(s.time) {
+			session = s.session
+		}
+	}
+
+	return session
+}
+
+// Next returns the start of the first session after t, and that session. If
+// the exchanges stay closed for a year after t, as they would only if the
+// holidays file said so, Next returns the time a year after t and Closed.
+func (c *Calendar) Next(t time.Time) (time.Time, Session) {
+	t = t.In(c.loc)
+	year, month, day := t.Date()
+
+	for i := 0; i <= 366; i++ {
+		for _, s := range c.sessions(year, month, day+i) {
+			if s.time.After(t) {
+				return s.time, s.session
+			}
+		}
+	}
+
+	return t.AddDate(1, 0, 0), Closed
+}
+
+// parseHolidays returns the kind of each date read from the holidays CSV.
+func parseHolidays(r io.Reader) (map[string]string, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: reading header: %v", ErrInvalidHolidays, err)
+	}
+
+	columns := map[string]int{"date": -1, "kind": -1}
+	for i, name := range header {
+		if _, ok := columns[strings.ToLower(name)]; ok {
+			columns[strings.ToLower(name)] = i
+		}
+	}
+	for name, i := range columns {
+		if i < 0 {
+			return nil, fmt.Errorf("%w: header missing %q column",
+				ErrInvalidHolidays, name)
+		}
+	}
+
+	days := make(map[string]string)
+	for n := 1; ; n++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidHolidays, err)
+		}
+		if len(record) <= columns["date"] || len(record) <= columns["kind"] {
+			return nil, fmt.Errorf("%w: record %d: missing columns",
+				ErrInvalidHolidays, n)
+		}
+
+		date, err := time.Parse("2006-01-02", record[columns["date"]])
+		if err != nil {
+			return nil, fmt.Errorf("%w: record %d: invalid date %q",
+				ErrInvalidHolidays, n, record[columns["date"]])
+		}
+
+		kind := strings.ToLower(record[columns["kind"]])
+		switch kind {
+		case closedDay, earlyDay:
+		default:
+			return nil, fmt.Errorf("%w: record %d: unknown kind %q",
+				ErrInvalidHolidays, n, record[columns["kind"]])
+		}
+
+		days[date.Format("2006-01-02")] = kind
+	}
+
+	return days, nil
+}
+
+// New returns a pointer to a new Calendar object after applying optional
+// settings.
+//
+// Defaults:
+//     HolidaysFile = "" (embedded holidays for 2021 through 2027)
+func New(options ...Option) (*Calendar, error) {
+	c := new(Calendar)
+
+	for _, option := range options {
+		option(c)
+	}
+
+	var err error
+	c.loc, err = time.LoadLocation(Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s time zone: %w", Timezone, err)
+	}
+
+	var r io.Reader = bytes.NewReader(embeddedHolidays)
+	if c.file != "" {
+		f, err := os.Open(c.file)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+	}
+
+	c.days, err = parseHolidays(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
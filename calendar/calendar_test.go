@@ -0,0 +1,133 @@
+package calendar
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSession(t *testing.T) {
+	t.Parallel()
+
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		time     string
+		expected Session
+	}{
+		{"2021-05-07T03:59:59-04:00", Closed},
+		{"2021-05-07T04:00:00-04:00", PreMarket},
+		{"2021-05-07T09:29:59-04:00", PreMarket},
+		{"2021-05-07T09:30:00-04:00", Regular},
+		{"2021-05-07T19:31:07Z", Regular}, // 3:31 p.m. Eastern
+		{"2021-05-07T16:00:00-04:00", AfterHours},
+		{"2021-05-07T20:00:00-04:00", Closed},
+		{"2021-05-08T12:00:00-04:00", Closed},     // Saturday
+		{"2021-01-04T09:30:00-05:00", Regular},    // standard time
+		{"2021-11-25T12:00:00-05:00", Closed},     // Thanksgiving
+		{"2021-11-26T13:00:00-05:00", AfterHours}, // early close
+		{"2021-11-26T17:00:00-05:00", Closed},
+	} {
+		at, err := time.Parse(time.RFC3339, tc.time)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := c.Session(at); actual != tc.expected {
+			t.Errorf("%s: actual session: %s; expected: %s", tc.time, actual,
+				tc.expected)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	t.Parallel()
+
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		time, next string
+		session    Session
+	}{
+		{"2021-05-07T09:30:00-04:00", "2021-05-07T16:00:00-04:00", AfterHours},
+		{"2021-05-07T20:00:00-04:00", "2021-05-10T04:00:00-04:00", PreMarket},
+		{"2021-11-24T21:00:00-05:00", "2021-11-26T04:00:00-05:00", PreMarket},
+		{"2021-11-26T10:00:00-05:00", "2021-11-26T13:00:00-05:00", AfterHours},
+		// the weekend straddles the change to daylight saving time
+		{"2021-03-12T20:00:00-05:00", "2021-03-15T04:00:00-04:00", PreMarket},
+	} {
+		at, err := time.Parse(time.RFC3339, tc.time)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := time.Parse(time.RFC3339, tc.next)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		next, session := c.Next(at)
+		if !next.Equal(expected) || session != tc.session {
+			t.Errorf("%s: actual next: %s %s; expected: %s %s", tc.time, next,
+				session, expected, tc.session)
+		}
+	}
+}
+
+func TestHolidaysFile(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "calendar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Logf("removing temp dir: %v", err)
+		}
+	}()
+
+	for _, tc := range []struct {
+		contents string
+		err      error
+	}{
+		{contents: "kind,date\nclosed,2021-05-07\n"},
+		{contents: "date,name\n2021-05-07,blah\n", err: ErrInvalidHolidays},
+		{contents: "date,kind\n05/07/2021,closed\n", err: ErrInvalidHolidays},
+		{contents: "date,kind\n2021-05-07,late\n", err: ErrInvalidHolidays},
+	} {
+		file := filepath.Join(dir, "holidays.csv")
+		err = ioutil.WriteFile(file, []byte(tc.contents), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c, err := New(HolidaysFile(file))
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%q: actual error: %v; expected: %v",
+				strings.TrimSpace(tc.contents), err, tc.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		// The file replaces the embedded holidays.
+		at := time.Date(2021, 5, 7, 12, 0, 0, 0, c.loc)
+		if s := c.Session(at); s != Closed {
+			t.Errorf("actual session on holiday: %s", s)
+		}
+		at = time.Date(2021, 11, 25, 12, 0, 0, 0, c.loc)
+		if s := c.Session(at); s != Regular {
+			t.Errorf("actual session on unlisted holiday: %s", s)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package calendar
+
+type Option func(*Calendar)
+
+// HolidaysFile reads holidays and early closes from the given CSV file
+// instead of the embedded one.
+func HolidaysFile(file string) Option {
+	return func(c *Calendar) {
+		c.file = file
+	}
+}
@@ -0,0 +1,15 @@
+package cmd
+
+// Finance providers and storage backends register themselves with the finance
+// and history packages, respectively, when imported. Import one here to make
+// it selectable with --provider or --storage.
+import (
+	_ "github.com/awoodbeck/faang-stonks/finance/failover"
+	_ "github.com/awoodbeck/faang-stonks/finance/iexcloud"
+	_ "github.com/awoodbeck/faang-stonks/finance/replay"
+	_ "github.com/awoodbeck/faang-stonks/finance/simulated"
+	_ "github.com/awoodbeck/faang-stonks/history/fanout"
+	_ "github.com/awoodbeck/faang-stonks/history/influxdb"
+	_ "github.com/awoodbeck/faang-stonks/history/memory"
+	_ "github.com/awoodbeck/faang-stonks/history/sqlite"
+)
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/history/sqlite"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	migrateCmd = &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply pending SQLite schema migrations.",
+		Long: `Apply pending SQLite schema migrations.
+
+The stonks command applies pending migrations when it starts. Use this command
+to upgrade a database ahead of a deployment instead.`,
+		Args: cobra.NoArgs,
+		RunE: migrateRun,
+	}
+
+	migrateStatusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "List SQLite schema migrations and whether they've been applied.",
+		Args:  cobra.NoArgs,
+		RunE:  migrateStatusRun,
+	}
+)
+
+func init() {
+	migrateCmd.AddCommand(migrateStatusCmd)
+	rootCmd.AddCommand(migrateCmd)
+}
+
+// openDatabase opens the SQLite database without applying migrations.
+func openDatabase() (*sqlite.Client, error) {
+	return sqlite.New(
+		sqlite.AutoMigrate(false),
+		sqlite.ConnMaxLifetime(viper.GetDuration("sqlite-conn-max-lifetime")),
+		sqlite.DatabaseFile(viper.GetString("sqlite-database")),
+		sqlite.MaxIdleConnections(viper.GetInt("sqlite-max-idle-conn")),
+	)
+}
+
+func migrateRun(_ *cobra.Command, _ []string) error {
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	applied, err := db.Migrate(context.Background())
+	for _, m := range applied {
+		fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(applied) == 0 {
+		fmt.Println("database is up to date")
+	}
+
+	return nil
+}
+
+func migrateStatusRun(_ *cobra.Command, _ []string) error {
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	status, err := db.MigrationStatus(context.Background())
+	if err != nil {
+		return err
+	}
+
+	pending := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+	for _, s := range status {
+		applied := "pending"
+		if s.Pending() {
+			pending++
+		} else {
+			applied = s.AppliedAt.Format(time.RFC3339)
+		}
+		_, _ = fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+
+	if pending == len(status) {
+		fmt.Println("no migrations applied")
+	}
+
+	return nil
+}
@@ -7,6 +7,7 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	_ "net/http/pprof"
@@ -16,12 +17,15 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/awoodbeck/faang-stonks/api"
+	"github.com/awoodbeck/faang-stonks/calendar"
 	"github.com/awoodbeck/faang-stonks/finance"
-	"github.com/awoodbeck/faang-stonks/finance/iexcloud"
-	"github.com/awoodbeck/faang-stonks/history/sqlite"
+	"github.com/awoodbeck/faang-stonks/history"
 	"github.com/awoodbeck/faang-stonks/poll"
+	"github.com/awoodbeck/faang-stonks/pubsub"
+	"github.com/awoodbeck/faang-stonks/spool"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
@@ -56,16 +60,20 @@ func init() {
 	})
 
 	// API server settings
+	rootCmd.Flags().String("api-admin-token", "", "bearer token required by the admin API, which is disabled if empty")
+	rootCmd.Flags().Bool("api-decimal-strings", false, "encode prices as JSON strings instead of numbers")
 	rootCmd.Flags().Duration("api-idle-timeout", api.DefaultIdleTimeout, "duration clients are allowed to idle")
 	rootCmd.Flags().StringP("api-listen-addr", "a", api.DefaultListenAddress, "API server host:port")
 	rootCmd.Flags().Bool("api-metrics", true, "enable metrics for the API server")
 	rootCmd.Flags().Duration("api-read-headers-timeout", api.DefaultReadHeaderTimeout, "duration clients have to send request headers")
+	rootCmd.Flags().Int("api-stream-backlog", pubsub.DefaultBacklog, "recently archived quotes kept for resuming stream clients")
+	rootCmd.Flags().Int("api-stream-buffer", pubsub.DefaultBufferSize, "quotes buffered per stream client before dropping the oldest")
+	rootCmd.Flags().Duration("api-stream-heartbeat", api.DefaultStreamHeartbeat, "duration between heartbeats sent to idle stream clients")
+	rootCmd.Flags().Int("api-ws-max-subscriptions", api.DefaultWebSocketMaxSubscriptions, "max symbols per WebSocket client")
+	rootCmd.Flags().Duration("api-ws-ping-interval", api.DefaultWebSocketPingInterval, "duration between pings sent to WebSocket clients")
 
-	// IEX Cloud API client settings
-	rootCmd.Flags().String("iex-batch-endpoint", iexcloud.DefaultBatchEndpoint, "IEX Cloud API batch endpoint URL")
-	rootCmd.Flags().Duration("iex-call-timeout", iexcloud.DefaultTimeout, "API call timeout")
-	rootCmd.Flags().Bool("iex-metrics", false, "collect metrics for IEX Cloud API calls")
-	rootCmd.Flags().StringP("iex-token", "t", "", "IEX Cloud API token")
+	// Finance provider settings
+	finance.AddFlags(rootCmd.Flags())
 
 	// Logger settings
 	rootCmd.Flags().StringP("log", "l", "stdout", "log file path")
@@ -75,27 +83,33 @@ func init() {
 	rootCmd.Flags().Int("log-max-backups", 5, "max number of old log files to retain")
 	rootCmd.Flags().Int("log-max-size", 100, "max log file size in MB before rotation")
 
-	// SQLite settings
-	rootCmd.Flags().Duration("sqlite-conn-max-lifetime", sqlite.DefaultConnsMaxLifetime, "max client connection lifetime")
-	rootCmd.Flags().StringP("sqlite-database", "d", sqlite.DefaultDatabaseFile, "database file path")
-	rootCmd.Flags().Int("sqlite-max-idle-conn", sqlite.DefaultMaxIdleConns, "max idle client connections")
+	// Storage backend settings are persistent so subcommands, like migrate,
+	// share them.
+	history.AddFlags(rootCmd.PersistentFlags())
 
 	// General settings
-	rootCmd.Flags().DurationP("poll", "p", poll.DefaultPollDuration, "duration between stock quote updates")
+	rootCmd.Flags().DurationP("poll", "p", poll.DefaultPollDuration, "duration between stock quote updates (during regular sessions if --poll-schedule is set)")
+	rootCmd.Flags().Duration("poll-after-hours", 5*time.Minute, "duration between updates during after-hours sessions; 0 skips them")
+	rootCmd.Flags().Duration("poll-closed", 0, "duration between updates while the market is closed; 0 skips polling")
+	rootCmd.Flags().String("poll-holidays", "", "market holidays CSV file (default embedded NYSE holidays)")
+	rootCmd.Flags().Duration("poll-pre-market", 5*time.Minute, "duration between updates during pre-market sessions; 0 skips them")
+	rootCmd.Flags().Bool("poll-schedule", false, "poll on a schedule that follows the market's trading sessions")
+	rootCmd.Flags().String("poll-spool", "", "file that buffers quotes while the archiver fails, which is disabled if empty")
 	rootCmd.Flags().String("pprof-addr", ":6060", "pprof host:port")
+	rootCmd.Flags().String("provider", "iexcloud", fmt.Sprintf("finance provider: %s", strings.Join(finance.Providers(), ", ")))
+	rootCmd.Flags().String("storage", "sqlite", fmt.Sprintf("storage backend: %s", strings.Join(history.Backends(), ", ")))
 	rootCmd.Flags().StringSliceP("symbols", "s", finance.DefaultSymbols, "stock symbols")
 	rootCmd.Flags().BoolP("verbose", "v", true, "verbose logging")
 
 	if err := viper.BindPFlags(rootCmd.Flags()); err != nil {
 		log.Fatalf("binding flags to viper: %s", err)
 	}
+	if err := viper.BindPFlags(rootCmd.PersistentFlags()); err != nil {
+		log.Fatalf("binding persistent flags to viper: %s", err)
+	}
 }
 
 func rootPreRun(_ *cobra.Command, _ []string) {
-	if viper.GetString("iex-token") == "" {
-		log.Fatal("IEX Cloud API token not set")
-	}
-
 	switch strings.ToLower(viper.GetString("log")) {
 	case "stdout", "":
 	default:
@@ -130,7 +144,7 @@ func rootRun(_ *cobra.Command, _ []string) {
 	).Sugar()
 	defer func() { _ = zl.Sync() }()
 
-	c := make(chan os.Signal)
+	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-c
@@ -142,12 +156,7 @@ func rootRun(_ *cobra.Command, _ []string) {
 		_ = http.ListenAndServe(viper.GetString("pprof-addr"), nil)
 	}()
 
-	storage, err := sqlite.New(
-		sqlite.ConnMaxLifetime(viper.GetDuration("sqlite-conn-max-lifetime")),
-		sqlite.DatabaseFile(viper.GetString("sqlite-database")),
-		sqlite.MaxIdleConnections(viper.GetInt("sqlite-max-idle-conn")),
-		sqlite.Symbols(viper.GetStringSlice("symbols")),
-	)
+	storage, err := history.New(viper.GetString("storage"), viper.GetViper(), zl)
 	if err != nil {
 		zl.Error(err)
 		gracefulExit(cancel, &ret)
@@ -160,23 +169,62 @@ func rootRun(_ *cobra.Command, _ []string) {
 		zl.Debug("archiver closed")
 	}()
 
-	var iexMetrics iexcloud.Option
-	if viper.GetBool("iex-metrics") {
-		iexMetrics = iexcloud.InstrumentHTTPClient()
+	quotes, err := finance.New(viper.GetString("provider"), viper.GetViper())
+	if err != nil {
+		zl.Errorf("%s provider: %v", viper.GetString("provider"), err)
+		gracefulExit(cancel, &ret)
 	}
 
-	quotes, err := iexcloud.New(
-		viper.GetString("iex-token"),
-		iexcloud.BatchEndpoint(viper.GetString("iex-batch-endpoint")),
-		iexcloud.CallTimeout(viper.GetDuration("iex-call-timeout")),
-		iexMetrics,
+	// The poller publishes archived quotes to the hub, which feeds the API's
+	// stream and WebSocket clients.
+	hub := pubsub.New(
+		pubsub.Backlog(viper.GetInt("api-stream-backlog")),
+		pubsub.BufferSize(viper.GetInt("api-stream-buffer")),
 	)
-	if err != nil {
-		zl.Error(err)
-		gracefulExit(cancel, &ret)
+
+	// Storage backends with a symbol registry let the admin API change the
+	// tracked symbols at runtime. Otherwise, the symbols are fixed.
+	var (
+		apiRegistry  api.Option
+		pollRegistry poll.Option
+	)
+	if registry, ok := storage.(history.SymbolRegistry); ok {
+		apiRegistry = api.Registry(registry)
+		pollRegistry = poll.Registry(registry)
+	}
+
+	var pollSchedule poll.Option
+	if viper.GetBool("poll-schedule") {
+		cal, err := calendar.New(
+			calendar.HolidaysFile(viper.GetString("poll-holidays")))
+		if err != nil {
+			zl.Errorf("market calendar: %v", err)
+			gracefulExit(cancel, &ret)
+		}
+		pollSchedule = poll.Schedule(cal, map[calendar.Session]time.Duration{
+			calendar.PreMarket:  viper.GetDuration("poll-pre-market"),
+			calendar.AfterHours: viper.GetDuration("poll-after-hours"),
+			calendar.Closed:     viper.GetDuration("poll-closed"),
+		})
 	}
 
-	poller, err := poll.New(quotes, storage, zl)
+	var pollSpool poll.Option
+	if file := viper.GetString("poll-spool"); file != "" {
+		s, err := spool.Open(file)
+		if err != nil {
+			zl.Error(err)
+			gracefulExit(cancel, &ret)
+		}
+		defer func() {
+			if err := s.Close(); err != nil {
+				zl.Errorf("closing spool: %v", err)
+			}
+		}()
+		pollSpool = poll.Spool(s)
+	}
+
+	poller, err := poll.New(quotes, storage, zl, poll.Notify(hub),
+		pollRegistry, pollSchedule, pollSpool)
 	if err != nil {
 		zl.Error(err)
 		gracefulExit(cancel, &ret)
@@ -194,16 +242,27 @@ func rootRun(_ *cobra.Command, _ []string) {
 		wg.Done()
 	}()
 
-	var apiMetrics api.Option
+	var apiDecimals, apiMetrics api.Option
+	if viper.GetBool("api-decimal-strings") {
+		apiDecimals = api.DecimalStrings()
+	}
 	if !viper.GetBool("api-metrics") {
 		apiMetrics = api.DisableInstrumentation()
 	}
 	server, err := api.New(
 		ctx, storage, zl,
+		apiDecimals,
 		apiMetrics,
+		apiRegistry,
+		api.AdminToken(viper.GetString("api-admin-token")),
 		api.IdleTimeout(viper.GetDuration("api-idle-timeout")),
 		api.ListenAddress(viper.GetString("api-listen-addr")),
 		api.ReadHeaderTimeout(viper.GetDuration("api-read-headers-timeout")),
+		api.Stream(hub),
+		api.StreamHeartbeat(viper.GetDuration("api-stream-heartbeat")),
+		api.Symbols(viper.GetStringSlice("symbols")),
+		api.WebSocketMaxSubscriptions(viper.GetInt("api-ws-max-subscriptions")),
+		api.WebSocketPingInterval(viper.GetDuration("api-ws-ping-interval")),
 	)
 	if err != nil {
 		zl.Error(err)
@@ -0,0 +1,146 @@
+package finance
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DecimalPlaces is the number of digits a Decimal holds after the decimal
+// point.
+const DecimalPlaces = 6
+
+// decimalScale is the number of Decimal units in one.
+const decimalScale = 1000000
+
+var (
+	ErrDecimalRange  = fmt.Errorf("decimal out of range")
+	ErrDecimalSyntax = fmt.Errorf("invalid decimal")
+)
+
+// Decimal is a fixed-point decimal number with DecimalPlaces digits after the
+// decimal point. Prices are Decimals so they round-trip and sum exactly,
+// unlike binary floating-point numbers such as 123.42.
+//
+// Decimal stores its value as an integer count of millionths, which is also
+// how it stores itself in a database.
+type Decimal int64
+
+// NewDecimal returns the Decimal nearest to f.
+func NewDecimal(f float64) Decimal {
+	return Decimal(math.Round(f * decimalScale))
+}
+
+// ParseDecimal returns the Decimal represented by s, which may be any decimal
+// literal, including those with exponents (e.g., "1.5e-3"). Digits beyond
+// DecimalPlaces round half away from zero.
+func ParseDecimal(s string) (Decimal, error) {
+	// big.Rat also accepts fractions, such as "1/3", which aren't decimals.
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || strings.Contains(s, "/") {
+		return 0, fmt.Errorf("%w: %q", ErrDecimalSyntax, s)
+	}
+	r.Mul(r, big.NewRat(decimalScale, 1))
+
+	// Round half away from zero.
+	num, denom := new(big.Int).Abs(r.Num()), r.Denom()
+	q, m := new(big.Int).QuoRem(num, denom, new(big.Int))
+	if m.Lsh(m, 1).Cmp(denom) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if r.Sign() < 0 {
+		q.Neg(q)
+	}
+
+	if !q.IsInt64() {
+		return 0, fmt.Errorf("%w: %q", ErrDecimalRange, s)
+	}
+
+	return Decimal(q.Int64()), nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics if s is invalid. It
+// simplifies initializing Decimals from constants.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+// Float64 returns the nearest floating-point number to d.
+func (d Decimal) Float64() float64 {
+	return float64(d) / decimalScale
+}
+
+// String returns d's shortest exact decimal representation (e.g., "123.42",
+// "-0.8", or "1299").
+func (d Decimal) String() string {
+	return string(d.append(nil))
+}
+
+func (d Decimal) append(b []byte) []byte {
+	u := uint64(d)
+	if d < 0 {
+		b = append(b, '-')
+		u = uint64(-d) // correct even for math.MinInt64, which negates to itself
+	}
+
+	b = strconv.AppendUint(b, u/decimalScale, 10)
+
+	frac := u % decimalScale
+	if frac == 0 {
+		return b
+	}
+
+	digits := []byte(strconv.FormatUint(frac+decimalScale, 10)[1:])
+
+	return append(append(b, '.'), bytes.TrimRight(digits, "0")...)
+}
+
+// MarshalJSON encodes d as a JSON number (e.g., 123.42).
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return d.append(nil), nil
+}
+
+// UnmarshalJSON decodes a JSON number or string into d. It ignores null.
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		return nil
+	}
+	if len(s) > 1 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+
+	v, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+
+	return nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (d *Decimal) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case int64:
+		*d = Decimal(v)
+	default:
+		return fmt.Errorf("cannot scan %T into a Decimal", src)
+	}
+
+	return nil
+}
+
+// Value implements the driver.Valuer interface.
+func (d Decimal) Value() (driver.Value, error) {
+	return int64(d), nil
+}
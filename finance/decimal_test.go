@@ -0,0 +1,111 @@
+package finance
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		s        string
+		expected Decimal
+		str      string
+		err      error
+	}{
+		{s: "123.42", expected: 123420000, str: "123.42"},
+		{s: "-0.8", expected: -800000, str: "-0.8"},
+		{s: "1299", expected: 1299000000, str: "1299"},
+		{s: "0", expected: 0, str: "0"},
+		{s: "0.000001", expected: 1, str: "0.000001"},
+		// halves round away from zero
+		{s: "0.0000005", expected: 1, str: "0.000001"},
+		{s: "-0.0000005", expected: -1, str: "-0.000001"},
+		{s: "0.0000004", expected: 0, str: "0"},
+		{s: "1.5e-3", expected: 1500, str: "0.0015"},
+		{s: "9.4042158e11", expected: 940421580000000000, str: "940421580000"},
+		{s: "1e13", err: ErrDecimalRange},
+		{s: "1/3", err: ErrDecimalSyntax},
+		{s: "", err: ErrDecimalSyntax},
+		{s: "price", err: ErrDecimalSyntax},
+	}
+
+	for i, tc := range testCases {
+		d, err := ParseDecimal(tc.s)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%d: actual error: %v; expected: %v", i, err, tc.err)
+			continue
+		}
+		if tc.err != nil {
+			continue
+		}
+
+		if d != tc.expected {
+			t.Errorf("%d: actual: %d; expected: %d", i, d, tc.expected)
+		}
+		if d.String() != tc.str {
+			t.Errorf("%d: actual string: %q; expected: %q", i, d.String(),
+				tc.str)
+		}
+	}
+}
+
+func TestDecimalExactSums(t *testing.T) {
+	t.Parallel()
+
+	var sum Decimal
+	for i := 0; i < 1000; i++ {
+		sum += MustParseDecimal("123.42")
+	}
+	if sum.String() != "123420" {
+		t.Errorf("actual sum: %s; expected: 123420", sum)
+	}
+
+	if d := NewDecimal(0.1 + 0.2); d.String() != "0.3" {
+		t.Errorf("actual: %s; expected: 0.3", d)
+	}
+	if f := MustParseDecimal("123.42").Float64(); f != 123.42 {
+		t.Errorf("actual float: %v; expected: 123.42", f)
+	}
+	if d := Decimal(math.MinInt64); d.String() != "-9223372036854.775808" {
+		t.Errorf("actual: %s", d)
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	t.Parallel()
+
+	q := struct {
+		Price Decimal  `json:"price"`
+		Open  *Decimal `json:"open"`
+	}{Price: MustParseDecimal("320.125")}
+
+	b, err := json.Marshal(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"price":320.125,"open":null}`; string(b) != expected {
+		t.Errorf("actual: %s; expected: %s", b, expected)
+	}
+
+	for _, s := range []string{
+		`{"price":320.125,"open":330.1}`,
+		`{"price":"320.125","open":"330.1"}`,
+	} {
+		q.Price, q.Open = 0, nil
+		if err := json.Unmarshal([]byte(s), &q); err != nil {
+			t.Fatal(err)
+		}
+		if q.Price.String() != "320.125" || q.Open == nil ||
+			q.Open.String() != "330.1" {
+			t.Errorf("%s decoded to %s, %v", s, q.Price, q.Open)
+		}
+	}
+
+	if err := json.Unmarshal([]byte(`{"price":true}`), &q); err == nil {
+		t.Error("expected an error decoding a boolean")
+	}
+}
@@ -0,0 +1,29 @@
+package finance
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SymbolErrors maps stock symbols to the errors that kept a provider from
+// returning their quotes. A provider that retrieves quotes for only some of
+// the given symbols returns those quotes alongside a SymbolErrors describing
+// the rest.
+type SymbolErrors map[string]error
+
+func (e SymbolErrors) Error() string {
+	symbols := make([]string, 0, len(e))
+	for symbol := range e {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	msgs := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", symbol, e[symbol]))
+	}
+
+	return fmt.Sprintf("quotes unavailable for %d symbol(s): %s", len(e),
+		strings.Join(msgs, "; "))
+}
@@ -0,0 +1,114 @@
+package failover
+
+import (
+	"sync"
+	"time"
+)
+
+// State is the state of a provider's circuit breaker.
+type State int
+
+const (
+	// Closed circuits pass calls through to the provider.
+	Closed State = iota
+
+	// HalfOpen circuits allow a single probe call through to the provider
+	// to determine whether it has recovered.
+	HalfOpen
+
+	// Open circuits skip the provider until the cooldown elapses.
+	Open
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case HalfOpen:
+		return "half-open"
+	case Open:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// breaker is a circuit breaker that opens after maxFailures consecutive
+// failures and allows a probe through after the cooldown elapses.
+type breaker struct {
+	cooldown    time.Duration
+	maxFailures int
+	now         func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	state    State
+}
+
+// allow returns true if a call may proceed. An open breaker whose cooldown has
+// elapsed becomes half-open and allows exactly one probe call until the probe
+// reports its result.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Closed:
+		return true
+	case Open:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = HalfOpen
+		return true
+	default: // a probe is in flight
+		return false
+	}
+}
+
+// failure records a failed call, opening the breaker if the call was a probe
+// or if it exhausted the allowed consecutive failures.
+func (b *breaker) failure() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == HalfOpen || b.failures >= b.maxFailures {
+		b.state = Open
+		b.openedAt = b.now()
+	}
+
+	return b.state
+}
+
+// abandon records a call that ended without a verdict on the provider's
+// health (e.g., the caller canceled it). A half-open breaker reopens so the
+// next call probes again.
+func (b *breaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == HalfOpen {
+		b.state = Open
+	}
+}
+
+// success records a successful call, closing the breaker.
+func (b *breaker) success() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.state = Closed
+
+	return b.state
+}
+
+// current returns the breaker's state.
+func (b *breaker) current() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
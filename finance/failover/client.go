@@ -0,0 +1,178 @@
+// Package failover provides a finance.Provider implementation that chains
+// other finance.Providers, trying each in priority order until one returns
+// quotes.
+//
+// Each provider sits behind its own circuit breaker. After a number of
+// consecutive failures, the breaker opens and the provider is skipped until a
+// cooldown elapses, at which point a single probe call determines whether the
+// provider has recovered. Every quote records the name of the provider that
+// served it.
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/awoodbeck/faang-stonks/metrics"
+	"go.uber.org/multierr"
+)
+
+const (
+	// DefaultCooldown is the default duration an open circuit waits before
+	// probing its provider.
+	DefaultCooldown = time.Minute
+
+	// DefaultMaxFailures is the default number of consecutive failures that
+	// open a provider's circuit.
+	DefaultMaxFailures = 3
+)
+
+var (
+	_ finance.Provider = (*Client)(nil)
+
+	ErrAllProvidersFailed = fmt.Errorf("all providers failed")
+	ErrNoProviders        = fmt.Errorf("no providers")
+)
+
+// Named pairs a provider with the name recorded on the quotes it serves.
+type Named struct {
+	Name     string
+	Provider finance.Provider
+}
+
+// Health describes a provider's circuit breaker.
+type Health struct {
+	Name  string
+	State State
+}
+
+// Client tries a prioritized list of providers.
+type Client struct {
+	cooldown    time.Duration
+	maxFailures int
+	now         func() time.Time
+	providers   []member
+}
+
+type member struct {
+	Named
+	breaker *breaker
+}
+
+// GetQuotes accepts one or more stock symbols and returns the quotes from the
+// first provider, in priority order, whose circuit allows the call and that
+// returns quotes. Partial results are returned alongside the provider's
+// finance.SymbolErrors. Providers with open circuits are skipped. It returns
+// an error wrapping ErrAllProvidersFailed and each provider's error if no
+// provider returns quotes.
+func (c *Client) GetQuotes(ctx context.Context, symbols ...string) (
+	[]finance.Quote, error) {
+	var errs error
+
+	for _, m := range c.providers {
+		if !m.breaker.allow() {
+			continue
+		}
+
+		quotes, err := m.Provider.GetQuotes(ctx, symbols...)
+
+		// Partial results mean the provider is up, so keep them.
+		var symErrs finance.SymbolErrors
+		if err != nil && len(quotes) > 0 && errors.As(err, &symErrs) {
+			state := m.breaker.success()
+			metrics.ProviderCircuitState.WithLabelValues(m.Name).Set(float64(state))
+			return stamp(quotes, m.Name), err
+		}
+
+		if err != nil {
+			if ctx.Err() != nil {
+				// The caller gave up; don't hold it against the provider.
+				m.breaker.abandon()
+				return nil, ctx.Err()
+			}
+
+			state := m.breaker.failure()
+			metrics.ProviderCircuitState.WithLabelValues(m.Name).Set(float64(state))
+			multierr.AppendInto(&errs, fmt.Errorf("%s: %w", m.Name, err))
+			continue
+		}
+
+		state := m.breaker.success()
+		metrics.ProviderCircuitState.WithLabelValues(m.Name).Set(float64(state))
+
+		return stamp(quotes, m.Name), nil
+	}
+
+	if errs == nil {
+		return nil, fmt.Errorf("%w: every circuit is open", ErrAllProvidersFailed)
+	}
+
+	return nil, fmt.Errorf("%w: %v", ErrAllProvidersFailed, errs)
+}
+
+// stamp records the provider name on quotes that don't already name one.
+func stamp(quotes []finance.Quote, name string) []finance.Quote {
+	for i := range quotes {
+		if quotes[i].Provider == "" {
+			quotes[i].Provider = name
+		}
+	}
+
+	return quotes
+}
+
+// Health returns the state of each provider's circuit breaker, in priority
+// order.
+func (c *Client) Health() []Health {
+	health := make([]Health, 0, len(c.providers))
+	for _, m := range c.providers {
+		health = append(health, Health{Name: m.Name, State: m.breaker.current()})
+	}
+
+	return health
+}
+
+// New accepts providers in priority order and returns a pointer to a new
+// Client object after applying optional settings.
+//
+// Defaults:
+//     Cooldown    = time.Minute
+//     MaxFailures = 3
+func New(providers []Named, options ...Option) (*Client, error) {
+	if len(providers) == 0 {
+		return nil, ErrNoProviders
+	}
+
+	c := &Client{
+		cooldown:    DefaultCooldown,
+		maxFailures: DefaultMaxFailures,
+		now:         time.Now,
+	}
+
+	for _, option := range options {
+		if option != nil {
+			option(c)
+		}
+	}
+
+	for _, p := range providers {
+		if p.Provider == nil {
+			return nil, fmt.Errorf("provider %q is nil", p.Name)
+		}
+
+		c.providers = append(c.providers, member{
+			Named: p,
+			breaker: &breaker{
+				cooldown:    c.cooldown,
+				maxFailures: c.maxFailures,
+				now:         c.now,
+			},
+		})
+		metrics.ProviderCircuitState.WithLabelValues(p.Name).Set(float64(Closed))
+	}
+
+	return c, nil
+}
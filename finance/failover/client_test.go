@@ -0,0 +1,241 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+)
+
+// dec parses a Decimal constant, such as a price.
+var dec = finance.MustParseDecimal
+
+var errOutage = fmt.Errorf("outage")
+
+// stubProvider returns a quote per symbol, or errOutage while it's down.
+type stubProvider struct {
+	mu    sync.Mutex
+	calls int
+	down  bool
+}
+
+func (s *stubProvider) GetQuotes(_ context.Context, symbols ...string) (
+	[]finance.Quote, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	if s.down {
+		return nil, errOutage
+	}
+
+	quotes := make([]finance.Quote, 0, len(symbols))
+	for _, symbol := range symbols {
+		quotes = append(quotes, finance.Quote{Price: dec("1"), Symbol: symbol})
+	}
+
+	return quotes, nil
+}
+
+func (s *stubProvider) setDown(down bool) {
+	s.mu.Lock()
+	s.down = down
+	s.mu.Unlock()
+}
+
+func (s *stubProvider) callCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls
+}
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	f.now = f.now.Add(d)
+	f.mu.Unlock()
+}
+
+func servedBy(t *testing.T, c *Client) string {
+	t.Helper()
+
+	quotes, err := c.GetQuotes(context.Background(), "fb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 1 {
+		t.Fatalf("expected 1 quote; actual: %d", len(quotes))
+	}
+
+	return quotes[0].Provider
+}
+
+func TestNewClient(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(nil)
+	if err != ErrNoProviders {
+		t.Errorf("expected: ErrNoProviders; actual: %v", err)
+	}
+
+	_, err = New([]Named{{Name: "nil"}})
+	if err == nil {
+		t.Error("expected an error for a nil provider")
+	}
+}
+
+func TestFailover(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Unix(1620415867, 0)}
+	primary, secondary := new(stubProvider), new(stubProvider)
+
+	c, err := New(
+		[]Named{
+			{Name: "primary", Provider: primary},
+			{Name: "secondary", Provider: secondary},
+		},
+		Clock(clock.Now),
+		Cooldown(time.Minute),
+		MaxFailures(2),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p := servedBy(t, c); p != "primary" {
+		t.Fatalf("expected primary; actual: %q", p)
+	}
+
+	// The primary fails over to the secondary, and its circuit opens after
+	// two consecutive failures.
+	primary.setDown(true)
+	for i := 0; i < 3; i++ {
+		if p := servedBy(t, c); p != "secondary" {
+			t.Fatalf("call %d: expected secondary; actual: %q", i, p)
+		}
+	}
+	if n := primary.callCount(); n != 3 {
+		t.Errorf("expected primary calls: 3; actual: %d", n)
+	}
+
+	expected := []Health{
+		{Name: "primary", State: Open},
+		{Name: "secondary", State: Closed},
+	}
+	if h := c.Health(); !reflect.DeepEqual(h, expected) {
+		t.Errorf("expected health: %v; actual: %v", expected, h)
+	}
+
+	// A failed probe after the cooldown reopens the circuit.
+	clock.Advance(time.Minute)
+	if p := servedBy(t, c); p != "secondary" {
+		t.Fatalf("expected secondary; actual: %q", p)
+	}
+	if n := primary.callCount(); n != 4 {
+		t.Errorf("expected primary calls: 4; actual: %d", n)
+	}
+	_ = servedBy(t, c)
+	if n := primary.callCount(); n != 4 {
+		t.Errorf("open circuit called primary: %d calls", n)
+	}
+
+	// A successful probe closes the circuit.
+	primary.setDown(false)
+	clock.Advance(time.Minute)
+	if p := servedBy(t, c); p != "primary" {
+		t.Fatalf("expected primary; actual: %q", p)
+	}
+	if s := c.Health()[0].State; s != Closed {
+		t.Errorf("expected primary circuit closed; actual: %s", s)
+	}
+}
+
+func TestAllProvidersFailed(t *testing.T) {
+	t.Parallel()
+
+	primary := &stubProvider{down: true}
+	secondary := &stubProvider{down: true}
+
+	c, err := New(
+		[]Named{
+			{Name: "primary", Provider: primary},
+			{Name: "secondary", Provider: secondary},
+		},
+		MaxFailures(1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.GetQuotes(context.Background(), "fb")
+	if !errors.Is(err, ErrAllProvidersFailed) {
+		t.Errorf("expected: ErrAllProvidersFailed; actual: %v", err)
+	}
+
+	// Both circuits are open now, so neither provider is called.
+	_, err = c.GetQuotes(context.Background(), "fb")
+	if !errors.Is(err, ErrAllProvidersFailed) {
+		t.Errorf("expected: ErrAllProvidersFailed; actual: %v", err)
+	}
+	if primary.callCount() != 1 || secondary.callCount() != 1 {
+		t.Errorf("expected 1 call each; actual: %d, %d", primary.callCount(),
+			secondary.callCount())
+	}
+}
+
+// partialProvider returns a quote for its first symbol and a
+// finance.SymbolErrors for the rest.
+type partialProvider struct{}
+
+func (partialProvider) GetQuotes(_ context.Context, symbols ...string) (
+	[]finance.Quote, error) {
+	errs := make(finance.SymbolErrors)
+	for _, symbol := range symbols[1:] {
+		errs[symbol] = errOutage
+	}
+
+	return []finance.Quote{{Price: dec("1"), Symbol: symbols[0]}}, errs
+}
+
+func TestPartialQuotes(t *testing.T) {
+	t.Parallel()
+
+	secondary := new(stubProvider)
+	c, err := New([]Named{
+		{Name: "primary", Provider: partialProvider{}},
+		{Name: "secondary", Provider: secondary},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	quotes, err := c.GetQuotes(context.Background(), "fb", "goog")
+	var errs finance.SymbolErrors
+	if !errors.As(err, &errs) || errs["goog"] != errOutage {
+		t.Errorf("expected finance.SymbolErrors for goog; actual: %v", err)
+	}
+	if len(quotes) != 1 || quotes[0].Provider != "primary" {
+		t.Errorf("expected 1 quote from primary; actual: %#v", quotes)
+	}
+	if secondary.callCount() != 0 {
+		t.Error("partial results failed over to the secondary")
+	}
+}
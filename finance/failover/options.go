@@ -0,0 +1,34 @@
+package failover
+
+import "time"
+
+type Option func(*Client)
+
+// Clock sets the function the circuit breakers use to tell the time.
+func Clock(now func() time.Time) Option {
+	return func(c *Client) {
+		if now != nil {
+			c.now = now
+		}
+	}
+}
+
+// Cooldown sets the duration an open circuit waits before probing its
+// provider.
+func Cooldown(d time.Duration) Option {
+	return func(c *Client) {
+		if d > 0 {
+			c.cooldown = d
+		}
+	}
+}
+
+// MaxFailures sets the number of consecutive failures that open a provider's
+// circuit.
+func MaxFailures(n int) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.maxFailures = n
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package failover
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/spf13/pflag"
+)
+
+func init() {
+	finance.Register("failover", finance.Backend{
+		Flags: func(fs *pflag.FlagSet) {
+			fs.Duration("failover-cooldown", DefaultCooldown, "duration before probing a failed provider")
+			fs.Int("failover-max-failures", DefaultMaxFailures, "consecutive failures before skipping a provider")
+			fs.StringSlice("failover-providers", nil, "finance providers to try, in priority order")
+		},
+		New: func(cfg finance.Config) (finance.Provider, error) {
+			var providers []Named
+
+			// Environment variables arrive as a single comma-separated value.
+			names := strings.Join(cfg.GetStringSlice("failover-providers"), ",")
+
+			for _, name := range strings.Split(names, ",") {
+				name = strings.TrimSpace(name)
+				switch name {
+				case "":
+					continue
+				case "failover":
+					return nil, fmt.Errorf("failover cannot chain itself")
+				}
+
+				p, err := finance.New(name, cfg)
+				if err != nil {
+					return nil, fmt.Errorf("%s provider: %w", name, err)
+				}
+
+				providers = append(providers, Named{Name: name, Provider: p})
+			}
+
+			return New(
+				providers,
+				Cooldown(cfg.GetDuration("failover-cooldown")),
+				MaxFailures(cfg.GetInt("failover-max-failures")),
+			)
+		},
+	})
+}
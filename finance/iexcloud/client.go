@@ -6,11 +6,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/awoodbeck/faang-stonks/finance"
@@ -21,8 +24,32 @@ const (
 	// Cloud API.
 	DefaultBatchEndpoint = "https://sandbox.iexapis.com/stable/stock/market/batch"
 
-	// DefaultTimeout is the default duration the client waits to a response.
+	// DefaultChunkSize is the default maximum number of symbols per batch
+	// request. It's the most the IEX Cloud batch endpoint accepts.
+	DefaultChunkSize = 100
+
+	// DefaultMaxBackoff is the default maximum delay between retries.
+	DefaultMaxBackoff = 5 * time.Second
+
+	// DefaultMaxRetries is the default number of times the client retries a
+	// request that failed with a retryable status.
+	DefaultMaxRetries = 3
+
+	// DefaultParallelism is the default maximum number of batch requests in
+	// flight at once.
+	DefaultParallelism = 4
+
+	// DefaultRetryBackoff is the default delay before the first retry. The
+	// delay doubles with each subsequent retry.
+	DefaultRetryBackoff = 250 * time.Millisecond
+
+	// DefaultTimeout is the default duration the client waits to a response,
+	// including retries.
 	DefaultTimeout = 10 * time.Second
+
+	// maxErrorBody is the most of an error response's body included in its
+	// StatusError.
+	maxErrorBody = 1 << 10
 )
 
 var (
@@ -34,6 +61,11 @@ var (
 // Client is an IEX Cloud API client.
 type Client struct {
 	batchEndpoint string
+	chunkSize     int
+	maxBackoff    time.Duration
+	maxRetries    int
+	parallelism   int
+	retryBackoff  time.Duration
 	timeout       time.Duration
 	token         string
 
@@ -42,26 +74,163 @@ type Client struct {
 
 // GetQuotes accepts one or more stock symbols and returns the current quote
 // for each stock from the IEX Cloud API.
+//
+// Symbols are requested in chunks of at most the chunk size, with no more than
+// the parallelism limit of requests in flight at once. If only some of the
+// symbols' quotes are available, because a chunk failed or the API omitted
+// unknown symbols, GetQuotes returns the available quotes alongside a
+// finance.SymbolErrors. If every chunk fails, it returns the first chunk's
+// error.
+//
+// Requests that fail with a retryable status are retried with exponential
+// backoff and jitter, honoring the response's Retry-After header. All requests
+// and retries share the call timeout. Failed responses return a *StatusError.
 func (c Client) GetQuotes(ctx context.Context, symbols ...string) (
 	[]finance.Quote, error) {
 	if len(symbols) == 0 {
 		return nil, fmt.Errorf("empty symbols")
 	}
 
+	callCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	chunks := chunk(symbols, c.chunkSize)
+	results := make([]chunkResult, len(chunks))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, c.parallelism)
+	for i := range chunks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i].quotes, results[i].err = c.fetch(callCtx, chunks[i])
+		}(i)
+	}
+	wg.Wait()
+
+	var (
+		failed int
+		quotes []finance.Quote
+		errs   = make(finance.SymbolErrors)
+	)
+
+	for i, r := range results {
+		if r.err != nil {
+			failed++
+			for _, symbol := range chunks[i] {
+				errs[strings.ToLower(symbol)] = r.err
+			}
+			continue
+		}
+
+		// The batch endpoint silently omits symbols it doesn't know.
+		found := make(map[string]struct{}, len(r.quotes))
+		for _, q := range r.quotes {
+			found[strings.ToLower(q.Symbol)] = struct{}{}
+		}
+		for _, symbol := range chunks[i] {
+			if _, ok := found[strings.ToLower(symbol)]; !ok {
+				errs[strings.ToLower(symbol)] = ErrBadSymbol
+			}
+		}
+
+		quotes = append(quotes, r.quotes...)
+	}
+
+	switch {
+	case failed == len(results):
+		return nil, results[0].err
+	case len(errs) > 0:
+		return quotes, errs
+	}
+
+	return quotes, nil
+}
+
+// chunkResult is the outcome of fetching one chunk of symbols.
+type chunkResult struct {
+	quotes []finance.Quote
+	err    error
+}
+
+// chunk splits the symbols into slices of at most size symbols.
+func chunk(symbols []string, size int) [][]string {
+	chunks := make([][]string, 0, (len(symbols)+size-1)/size)
+	for len(symbols) > size {
+		chunks = append(chunks, symbols[:size])
+		symbols = symbols[size:]
+	}
+
+	return append(chunks, symbols)
+}
+
+// fetch retrieves the quotes for the symbols in a single batch request,
+// retrying retryable failures as the context allows.
+func (c Client) fetch(ctx context.Context, symbols []string) ([]finance.Quote,
+	error) {
 	v := url.Values{}
 	v.Add("types", "quote")
 	v.Add("token", c.token)
 	v.Add("symbols", strings.ToLower(strings.Join(symbols, ",")))
+	u := fmt.Sprintf("%s?%s", c.batchEndpoint, v.Encode())
 
-	callCtx, cancel := context.WithTimeout(ctx, c.timeout)
-	defer cancel()
+	for attempt := 0; ; attempt++ {
+		quotes, err := c.getQuotes(ctx, u)
+		if err == nil {
+			return quotes, nil
+		}
 
-	req, err := http.NewRequestWithContext(
-		callCtx,
-		http.MethodGet,
-		fmt.Sprintf("%s?%s", c.batchEndpoint, v.Encode()),
-		nil,
-	)
+		var se *StatusError
+		if !errors.As(err, &se) || !se.Retryable() || attempt >= c.maxRetries {
+			return nil, err
+		}
+
+		delay := se.RetryAfter
+		if delay == 0 {
+			delay = c.backoff(attempt)
+		}
+
+		// Don't bother waiting if the call would time out first.
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return nil, err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the delay before the given retry attempt: the retry backoff
+// doubled for each previous attempt, capped at the maximum backoff, with
+// jitter applied to the latter half of the delay so concurrent clients don't
+// retry in lockstep.
+func (c Client) backoff(attempt int) time.Duration {
+	d := c.retryBackoff
+	for i := 0; i < attempt && d < c.maxBackoff; i++ {
+		d *= 2
+	}
+	if d > c.maxBackoff {
+		d = c.maxBackoff
+	}
+
+	half := d / 2
+
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+// getQuotes makes a single request to the given batch URL.
+func (c Client) getQuotes(ctx context.Context, u string) ([]finance.Quote,
+	error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -77,11 +246,12 @@ func (c Client) GetQuotes(ctx context.Context, symbols ...string) (
 
 	if resp.StatusCode != http.StatusOK {
 		buf := new(bytes.Buffer)
-		_, err = io.Copy(buf, resp.Body)
+		_, err = io.Copy(buf, io.LimitReader(resp.Body, maxErrorBody))
 		if err != nil {
 			return nil, err
 		}
-		return nil, fmt.Errorf("decoding response: %s", buf)
+		return nil, newStatusError(resp.StatusCode, buf.String(),
+			resp.Header.Get("Retry-After"))
 	}
 
 	b := make(batchQuotes)
@@ -98,6 +268,11 @@ func (c Client) GetQuotes(ctx context.Context, symbols ...string) (
 // Defaults:
 //     BatchEndpoint = "https://sandbox.iexapis.com/stable/stock/market/batch"
 //     CallTimeout   = 10 * time.Second
+//     ChunkSize     = 100
+//     MaxBackoff    = 5 * time.Second
+//     MaxRetries    = 3
+//     Parallelism   = 4
+//     RetryBackoff  = 250 * time.Millisecond
 func New(token string, options ...Option) (*Client, error) {
 	if token == "" {
 		return nil, ErrInvalidToken
@@ -105,7 +280,12 @@ func New(token string, options ...Option) (*Client, error) {
 
 	c := &Client{
 		batchEndpoint: DefaultBatchEndpoint,
+		chunkSize:     DefaultChunkSize,
 		httpClient:    http.DefaultClient,
+		maxBackoff:    DefaultMaxBackoff,
+		maxRetries:    DefaultMaxRetries,
+		parallelism:   DefaultParallelism,
+		retryBackoff:  DefaultRetryBackoff,
 		timeout:       DefaultTimeout,
 		token:         token,
 	}
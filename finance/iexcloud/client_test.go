@@ -2,7 +2,16 @@ package iexcloud
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,6 +20,9 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// dec parses a Decimal constant, such as a price.
+var dec = finance.MustParseDecimal
+
 func TestNewClientDefaults(t *testing.T) {
 	t.Parallel()
 
@@ -113,3 +125,275 @@ func TestClientGetQuotes(t *testing.T) {
 		}
 	}
 }
+
+// statusServer responds to each request with the next status in its list,
+// then with a successful batch response once the list is exhausted.
+type statusServer struct {
+	mu         sync.Mutex
+	requests   int
+	retryAfter string
+	statuses   []int
+}
+
+func (s *statusServer) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+	if len(s.statuses) > 0 {
+		status := s.statuses[0]
+		s.statuses = s.statuses[1:]
+		if s.retryAfter != "" {
+			w.Header().Set("Retry-After", s.retryAfter)
+		}
+		w.WriteHeader(status)
+		_, _ = fmt.Fprintln(w, http.StatusText(status))
+		return
+	}
+
+	_, _ = w.Write([]byte(`{"FB":{"quote":{"symbol":"FB","latestPrice":320.125,"latestUpdate":1620415867272}}}`))
+}
+
+func (s *statusServer) requestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+func TestClientGetQuotesRetries(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		statuses   []int
+		retryAfter string
+		timeout    time.Duration
+		err        error
+		requests   int
+	}{
+		{ // transient errors are retried
+			statuses: []int{http.StatusServiceUnavailable,
+				http.StatusTooManyRequests},
+			requests: 3,
+		},
+		{ // retries are exhausted
+			statuses: []int{http.StatusBadGateway, http.StatusBadGateway,
+				http.StatusBadGateway, http.StatusBadGateway},
+			err:      ErrServer,
+			requests: 4,
+		},
+		{ // permanent errors are not retried
+			statuses: []int{http.StatusUnauthorized},
+			err:      ErrUnauthorized,
+			requests: 1,
+		},
+		{
+			statuses: []int{http.StatusNotFound},
+			err:      ErrBadSymbol,
+			requests: 1,
+		},
+		{ // Retry-After is honored, but not beyond the call timeout
+			statuses:   []int{http.StatusTooManyRequests},
+			retryAfter: "60",
+			timeout:    time.Second,
+			err:        ErrRateLimited,
+			requests:   1,
+		},
+	}
+
+	for i, tc := range testCases {
+		s := &statusServer{retryAfter: tc.retryAfter, statuses: tc.statuses}
+		srv := httptest.NewServer(s)
+
+		c, err := New("stonks!", BatchEndpoint(srv.URL),
+			CallTimeout(tc.timeout), RetryBackoff(time.Millisecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		quotes, err := c.GetQuotes(context.Background(), "fb")
+		srv.Close()
+
+		if tc.err == nil {
+			if err != nil {
+				t.Errorf("%d: unexpected error: %v", i, err)
+			} else if len(quotes) != 1 || quotes[0].Price != dec("320.125") {
+				t.Errorf("%d: unexpected quotes: %#v", i, quotes)
+			}
+		} else {
+			var se *StatusError
+			if !errors.Is(err, tc.err) || !errors.As(err, &se) {
+				t.Errorf("%d: expected: %v; actual: %v", i, tc.err, err)
+			}
+		}
+
+		if n := s.requestCount(); n != tc.requests {
+			t.Errorf("%d: expected requests: %d; actual: %d", i, tc.requests, n)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2021, 5, 7, 19, 31, 7, 0, time.UTC)
+
+	testCases := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"-1", 0},
+		{"soon", 0},
+		{"Fri, 07 May 2021 19:31:37 GMT", 30 * time.Second},
+		{"Fri, 07 May 2021 19:30:00 GMT", 0},
+	}
+
+	for i, tc := range testCases {
+		if actual := parseRetryAfter(tc.value, now); actual != tc.expected {
+			t.Errorf("%d: expected: %v; actual: %v", i, tc.expected, actual)
+		}
+	}
+}
+
+func TestClientBackoff(t *testing.T) {
+	t.Parallel()
+
+	c, err := New("stonks!", RetryBackoff(100*time.Millisecond),
+		MaxBackoff(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for attempt, max := range []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	} {
+		for i := 0; i < 100; i++ {
+			d := c.backoff(attempt)
+			if d < max/2 || d > max {
+				t.Fatalf("attempt %d: backoff %v outside [%v, %v]", attempt, d,
+					max/2, max)
+			}
+		}
+	}
+}
+
+// chunkServer answers batch requests with a quote for each requested symbol,
+// except unknown symbols, which it omits, and it fails any request that
+// includes the "down" symbol. It records the largest batch and the most
+// requests in flight at once.
+type chunkServer struct {
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	maxBatch    int
+}
+
+func (s *chunkServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	symbols := strings.Split(r.URL.Query().Get("symbols"), ",")
+
+	s.mu.Lock()
+	s.inFlight++
+	if s.inFlight > s.maxInFlight {
+		s.maxInFlight = s.inFlight
+	}
+	if len(symbols) > s.maxBatch {
+		s.maxBatch = len(symbols)
+	}
+	s.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	s.mu.Lock()
+	s.inFlight--
+	s.mu.Unlock()
+
+	b := make(batchQuotes)
+	for _, symbol := range symbols {
+		switch symbol {
+		case "down":
+			http.Error(w, "Unknown symbol", http.StatusNotFound)
+			return
+		case "unknown":
+			continue
+		}
+		b[strings.ToUpper(symbol)] = map[string]quote{"quote": {
+			Symbol:    strings.ToUpper(symbol),
+			Price:     dec("1"),
+			Timestamp: 1620415867272,
+		}}
+	}
+
+	_ = json.NewEncoder(w).Encode(b)
+}
+
+func TestClientGetQuotesChunks(t *testing.T) {
+	t.Parallel()
+
+	s := new(chunkServer)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	c, err := New("stonks!", BatchEndpoint(srv.URL), ChunkSize(3),
+		Parallelism(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	symbols := []string{
+		"a", "b", "c",
+		"d", "e", "down",
+		"g", "h", "unknown",
+		"j", "k", "l",
+		"m",
+	}
+	quotes, err := c.GetQuotes(context.Background(), symbols...)
+
+	var errs finance.SymbolErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected finance.SymbolErrors; actual: %v", err)
+	}
+	for _, symbol := range []string{"d", "e", "down"} {
+		if !errors.Is(errs[symbol], ErrBadSymbol) {
+			t.Errorf("%s: expected: ErrBadSymbol; actual: %v", symbol,
+				errs[symbol])
+		}
+	}
+	if errs["unknown"] != ErrBadSymbol {
+		t.Errorf("unknown: expected: ErrBadSymbol; actual: %v", errs["unknown"])
+	}
+	if len(errs) != 4 {
+		t.Errorf("expected 4 symbol errors; actual: %v", errs)
+	}
+
+	actual := make([]string, 0, len(quotes))
+	for _, q := range quotes {
+		actual = append(actual, strings.ToLower(q.Symbol))
+	}
+	sort.Strings(actual)
+	expected := []string{"a", "b", "c", "g", "h", "j", "k", "l", "m"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected symbols: %v; actual: %v", expected, actual)
+	}
+
+	if s.maxBatch > 3 {
+		t.Errorf("expected at most 3 symbols per request; actual: %d",
+			s.maxBatch)
+	}
+	if s.maxInFlight > 2 {
+		t.Errorf("expected at most 2 requests in flight; actual: %d",
+			s.maxInFlight)
+	}
+
+	// When every chunk fails, the error is the first chunk's.
+	_, err = c.GetQuotes(context.Background(), "down", "down")
+	if !errors.Is(err, ErrBadSymbol) {
+		t.Errorf("expected: ErrBadSymbol; actual: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package iexcloud
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrBadSymbol        = fmt.Errorf("bad symbol")
+	ErrRateLimited      = fmt.Errorf("rate limited")
+	ErrServer           = fmt.Errorf("server error")
+	ErrUnauthorized     = fmt.Errorf("unauthorized")
+	ErrUnexpectedStatus = fmt.Errorf("unexpected status")
+)
+
+// StatusError describes a non-200 response from the IEX Cloud API. It wraps
+// one of ErrBadSymbol, ErrRateLimited, ErrServer, ErrUnauthorized, or
+// ErrUnexpectedStatus, so callers can test for the kind of failure with
+// errors.Is.
+type StatusError struct {
+	StatusCode int
+	Message    string
+
+	// RetryAfter is the delay requested by the response's Retry-After
+	// header, if any.
+	RetryAfter time.Duration
+
+	err error
+}
+
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("%v (%d %s)", e.err, e.StatusCode,
+		http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+
+	return msg
+}
+
+func (e *StatusError) Unwrap() error { return e.err }
+
+// Retryable returns true if the request may succeed if tried again.
+func (e *StatusError) Retryable() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// newStatusError returns a StatusError for the response with the given status
+// code, message body, and Retry-After header value.
+func newStatusError(code int, message, retryAfter string) *StatusError {
+	e := &StatusError{
+		StatusCode: code,
+		Message:    strings.TrimSpace(message),
+		RetryAfter: parseRetryAfter(retryAfter, time.Now()),
+	}
+
+	switch {
+	case code == http.StatusTooManyRequests:
+		e.err = ErrRateLimited
+	case code == http.StatusUnauthorized, code == http.StatusForbidden:
+		e.err = ErrUnauthorized
+	case code == http.StatusBadRequest, code == http.StatusNotFound:
+		e.err = ErrBadSymbol
+	case code >= 500:
+		e.err = ErrServer
+	default:
+		e.err = ErrUnexpectedStatus
+	}
+
+	return e
+}
+
+// parseRetryAfter returns the delay in a Retry-After header value, which is
+// either a number of seconds or an HTTP date. It returns zero if the value is
+// empty, malformed, or in the past.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+
+	return 0
+}
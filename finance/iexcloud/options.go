@@ -30,6 +30,15 @@ func CallTimeout(timeout time.Duration) Option {
 	}
 }
 
+// ChunkSize accepts the maximum number of symbols per batch request.
+func ChunkSize(n int) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.chunkSize = n
+		}
+	}
+}
+
 // InstrumentHTTPClient replaces the default HTTP client with an instrumented
 // version compatible with Prometheus.
 func InstrumentHTTPClient() Option {
@@ -66,3 +75,41 @@ func InstrumentHTTPClient() Option {
 		c.httpClient.Transport = roundTripper
 	}
 }
+
+// MaxBackoff accepts the maximum delay between retries.
+func MaxBackoff(d time.Duration) Option {
+	return func(c *Client) {
+		if d > 0 {
+			c.maxBackoff = d
+		}
+	}
+}
+
+// MaxRetries accepts the number of times the client retries a request that
+// failed with a retryable status. Zero disables retries.
+func MaxRetries(n int) Option {
+	return func(c *Client) {
+		if n >= 0 {
+			c.maxRetries = n
+		}
+	}
+}
+
+// Parallelism accepts the maximum number of batch requests in flight at once.
+func Parallelism(n int) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.parallelism = n
+		}
+	}
+}
+
+// RetryBackoff accepts the delay before the first retry. The delay doubles
+// with each subsequent retry, up to the maximum backoff.
+func RetryBackoff(d time.Duration) Option {
+	return func(c *Client) {
+		if d > 0 {
+			c.retryBackoff = d
+		}
+	}
+}
@@ -9,10 +9,29 @@ import (
 
 // quote is an IEX Cloud-specific quote. It's used as an intermediate type to
 // translate an IEX Cloud JSON to the finance.Quote type.
+//
+// IEX Cloud timestamps, such as latestUpdate, are milliseconds since the Unix
+// epoch.
 type quote struct {
-	Symbol    string  `json:"symbol"`
-	Price     float64 `json:"latestPrice"`
-	Timestamp int64   `json:"latestUpdate"`
+	Symbol    string          `json:"symbol"`
+	Price     finance.Decimal `json:"latestPrice"`
+	Timestamp int64           `json:"latestUpdate"`
+
+	Source           string `json:"latestSource"`
+	CalculationPrice string `json:"calculationPrice"`
+
+	Open          *finance.Decimal `json:"open"`
+	High          *finance.Decimal `json:"high"`
+	Low           *finance.Decimal `json:"low"`
+	Close         *finance.Decimal `json:"close"`
+	Volume        *int64           `json:"volume"`
+	PreviousClose *finance.Decimal `json:"previousClose"`
+	Change        *finance.Decimal `json:"change"`
+	ChangePercent *float64         `json:"changePercent"`
+	MarketCap     *int64           `json:"marketCap"`
+	PERatio       *float64         `json:"peRatio"`
+	Week52High    *finance.Decimal `json:"week52High"`
+	Week52Low     *finance.Decimal `json:"week52Low"`
 }
 
 type batchQuotes map[string]map[string]quote
@@ -30,7 +49,23 @@ func (b batchQuotes) MarshalQuotes() ([]finance.Quote, error) {
 		quotes = append(quotes, finance.Quote{
 			Symbol: q.Symbol,
 			Price:  q.Price,
-			Time:   time.Unix(q.Timestamp/1000, q.Timestamp%1000),
+			Time:   time.Unix(0, q.Timestamp*int64(time.Millisecond)).UTC(),
+
+			Source:           q.Source,
+			CalculationPrice: q.CalculationPrice,
+
+			Open:          q.Open,
+			High:          q.High,
+			Low:           q.Low,
+			Close:         q.Close,
+			Volume:        q.Volume,
+			PreviousClose: q.PreviousClose,
+			Change:        q.Change,
+			ChangePercent: q.ChangePercent,
+			MarketCap:     q.MarketCap,
+			PERatio:       q.PERatio,
+			Week52High:    q.Week52High,
+			Week52Low:     q.Week52Low,
 		})
 	}
 
@@ -3,31 +3,180 @@ package iexcloud
 import (
 	"bytes"
 	"encoding/json"
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
 )
 
 func TestBatchQuotesUnmarshalJSON(t *testing.T) {
 	t.Parallel()
 
-	for i, q := range []string{quoteClosed, quoteOpen} {
+	type expected struct {
+		price            string
+		time             time.Time
+		source           string
+		calculationPrice string
+	}
+
+	ms := func(ms int64) time.Time {
+		return time.Unix(0, ms*int64(time.Millisecond)).UTC()
+	}
+
+	testCases := []struct {
+		json     string
+		expected map[string]expected
+	}{
+		{
+			json: quoteClosed,
+			expected: map[string]expected{
+				"FB": {"329.51", time.Date(2021, 4, 29, 20, 0, 0, 376000000,
+					time.UTC), "Close", "close"},
+				"AMZN": {"3471.31", ms(1619726400630), "Close", "close"},
+				"AAPL": {"133.48", ms(1619726400568), "Close", "close"},
+				"NFLX": {"509", ms(1619726400830), "Close", "close"},
+				"GOOG": {"2429.89", ms(1619726400449), "Close", "close"},
+			},
+		},
+		{
+			json: quoteOpen,
+			expected: map[string]expected{
+				"AMZN": {"3539.455", ms(1619791413494), "IEX real time price",
+					"tops"},
+				"AAPL": {"132.695", ms(1619791413349), "IEX real time price",
+					"tops"},
+				"FB": {"328.71", time.Date(2021, 4, 30, 14, 3, 31, 927000000,
+					time.UTC), "IEX real time price", "tops"},
+				"NFLX": {"508.81", ms(1619791408157), "IEX real time price",
+					"tops"},
+				"GOOG": {"2424.86", ms(1619791408157), "IEX real time price",
+					"tops"},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
 		b := make(batchQuotes)
-		err := json.NewDecoder(bytes.NewBufferString(q)).Decode(&b)
+		err := json.NewDecoder(bytes.NewBufferString(tc.json)).Decode(&b)
 		if err != nil {
 			t.Errorf("%d: %v", i, err)
 			continue
 		}
 
-		_, err = b.MarshalQuotes()
+		quotes, err := b.MarshalQuotes()
 		if err != nil {
 			t.Errorf("%d: %v", i, err)
 			continue
 		}
+
+		if len(quotes) != len(tc.expected) {
+			t.Errorf("%d: actual quotes: %d; expected: %d", i, len(quotes),
+				len(tc.expected))
+		}
+
+		for _, q := range quotes {
+			e, ok := tc.expected[q.Symbol]
+			if !ok {
+				t.Errorf("%d: unexpected symbol %q", i, q.Symbol)
+				continue
+			}
+
+			if q.Price.String() != e.price {
+				t.Errorf("%d: %s: actual price: %s; expected: %s", i, q.Symbol,
+					q.Price, e.price)
+			}
+			if !q.Time.Equal(e.time) || q.Time.Location() != time.UTC {
+				t.Errorf("%d: %s: actual time: %s; expected: %s", i, q.Symbol,
+					q.Time, e.time)
+			}
+			if q.Source != e.source {
+				t.Errorf("%d: %s: actual source: %q; expected: %q", i,
+					q.Symbol, q.Source, e.source)
+			}
+			if q.CalculationPrice != e.calculationPrice {
+				t.Errorf("%d: %s: actual calculation price: %q; expected: %q",
+					i, q.Symbol, q.CalculationPrice, e.calculationPrice)
+			}
+		}
+	}
+}
+
+func TestBatchQuotesDailyStats(t *testing.T) {
+	t.Parallel()
+
+	quotes := make(map[string]finance.Quote)
+	for i, q := range []string{quoteClosed, quoteOpen} {
+		b := make(batchQuotes)
+		err := json.NewDecoder(bytes.NewBufferString(q)).Decode(&b)
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+
+		batch, err := b.MarshalQuotes()
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+
+		// The open market quote of each symbol replaces the closed one.
+		for _, quote := range batch {
+			quotes[quote.Symbol] = quote
+		}
+
+		if i == 0 {
+			fb := quotes["FB"]
+			expected := finance.Quote{
+				Open:          decimal("330.1"),
+				High:          decimal("331.81"),
+				Low:           decimal("321.61"),
+				Close:         decimal("329.51"),
+				Volume:        integer(56526771),
+				PreviousClose: decimal("307.1"),
+				Change:        decimal("22.41"),
+				ChangePercent: float(0.07297),
+				MarketCap:     integer(940421582177),
+				PERatio:       float(32.66),
+				Week52High:    decimal("331.81"),
+				Week52Low:     decimal("198.76"),
+			}
+			expected.Price, expected.Symbol, expected.Time = fb.Price,
+				fb.Symbol, fb.Time
+			expected.Source, expected.CalculationPrice = fb.Source,
+				fb.CalculationPrice
+			if !reflect.DeepEqual(fb, expected) {
+				t.Errorf("closed FB quote not equal to expected")
+				t.Logf("expected: %#v", expected)
+				t.Logf("actual:   %#v", fb)
+			}
+
+			if quotes["GOOG"].PERatio != nil {
+				t.Errorf("expected nil GOOG P/E ratio; actual: %v",
+					*quotes["GOOG"].PERatio)
+			}
+		}
 	}
 
-	// TODO: Improve this test by verifying values in the JSON were properly
-	// decoded.
+	// The open, high, low, close, and volume are unknown while the market is
+	// open.
+	fb := quotes["FB"]
+	if fb.Open != nil || fb.High != nil || fb.Low != nil || fb.Close != nil ||
+		fb.Volume != nil {
+		t.Errorf("expected nil daily statistics: %#v", fb)
+	}
+	if fb.PreviousClose == nil || fb.PreviousClose.String() != "329.51" {
+		t.Errorf("expected previous close 329.51: %v", fb.PreviousClose)
+	}
 }
 
+func decimal(s string) *finance.Decimal {
+	d := finance.MustParseDecimal(s)
+	return &d
+}
+
+func float(f float64) *float64 { return &f }
+
+func integer(i int64) *int64 { return &i }
+
 // TestBatchQuotesMalformedJSON tests a use case that shouldn't ever happen
 // unless something drastically changes with the IEX Cloud's API output.
 func TestBatchQuotesMalformedJSON(t *testing.T) {
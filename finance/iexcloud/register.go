@@ -0,0 +1,40 @@
+package iexcloud
+
+import (
+	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/spf13/pflag"
+)
+
+func init() {
+	finance.Register("iexcloud", finance.Backend{
+		Flags: func(fs *pflag.FlagSet) {
+			fs.String("iex-batch-endpoint", DefaultBatchEndpoint, "IEX Cloud API batch endpoint URL")
+			fs.Duration("iex-call-timeout", DefaultTimeout, "API call timeout")
+			fs.Int("iex-chunk-size", DefaultChunkSize, "maximum symbols per batch request")
+			fs.Duration("iex-max-backoff", DefaultMaxBackoff, "maximum delay between API call retries")
+			fs.Int("iex-max-retries", DefaultMaxRetries, "API call retries after retryable errors")
+			fs.Bool("iex-metrics", false, "collect metrics for IEX Cloud API calls")
+			fs.Int("iex-parallelism", DefaultParallelism, "maximum concurrent batch requests")
+			fs.Duration("iex-retry-backoff", DefaultRetryBackoff, "delay before the first API call retry")
+			fs.StringP("iex-token", "t", "", "IEX Cloud API token")
+		},
+		New: func(cfg finance.Config) (finance.Provider, error) {
+			var metrics Option
+			if cfg.GetBool("iex-metrics") {
+				metrics = InstrumentHTTPClient()
+			}
+
+			return New(
+				cfg.GetString("iex-token"),
+				BatchEndpoint(cfg.GetString("iex-batch-endpoint")),
+				CallTimeout(cfg.GetDuration("iex-call-timeout")),
+				ChunkSize(cfg.GetInt("iex-chunk-size")),
+				MaxBackoff(cfg.GetDuration("iex-max-backoff")),
+				MaxRetries(cfg.GetInt("iex-max-retries")),
+				Parallelism(cfg.GetInt("iex-parallelism")),
+				RetryBackoff(cfg.GetDuration("iex-retry-backoff")),
+				metrics,
+			)
+		},
+	})
+}
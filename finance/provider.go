@@ -7,6 +7,8 @@ package finance
 import "context"
 
 // Provider describes an object that returns one quote per given stock symbol.
+// A provider may return the quotes it could retrieve alongside a SymbolErrors
+// error describing the symbols it could not.
 type Provider interface {
 	GetQuotes(ctx context.Context, symbol ...string) ([]Quote, error)
 }
@@ -4,11 +4,37 @@ import "time"
 
 var DefaultSymbols = []string{"fb", "amzn", "aapl", "nflx", "goog"}
 
-// Quote represents the snapshot of a stock's price.
+// Quote represents the snapshot of a stock's price. Provider names the finance
+// provider that served the quote, if known. Source describes where the
+// provider got the price (e.g., "IEX real time price" or "Close"), and
+// CalculationPrice names the provider's method of calculating it (e.g.,
+// "tops", "sip", or "close"). Both are empty if unknown.
+//
+// Prices are Decimals. The remaining fields are the stock's daily statistics,
+// which are optional. Providers leave them nil if they don't supply them or,
+// as with the open, high, low, close, and volume while the market is open, if
+// they aren't yet known.
 type Quote struct {
-	Price  float64   `json:"price"`
-	Symbol string    `json:"symbol"`
-	Time   time.Time `json:"time"`
+	Price    Decimal   `json:"price"`
+	Symbol   string    `json:"symbol"`
+	Time     time.Time `json:"time"`
+	Provider string    `json:"provider,omitempty"`
+
+	Source           string `json:"source,omitempty"`
+	CalculationPrice string `json:"calculationPrice,omitempty"`
+
+	Open          *Decimal `json:"open,omitempty"`
+	High          *Decimal `json:"high,omitempty"`
+	Low           *Decimal `json:"low,omitempty"`
+	Close         *Decimal `json:"close,omitempty"`
+	Volume        *int64   `json:"volume,omitempty"`
+	PreviousClose *Decimal `json:"previousClose,omitempty"`
+	Change        *Decimal `json:"change,omitempty"`
+	ChangePercent *float64 `json:"changePercent,omitempty"`
+	MarketCap     *int64   `json:"marketCap,omitempty"`
+	PERatio       *float64 `json:"peRatio,omitempty"`
+	Week52High    *Decimal `json:"week52High,omitempty"`
+	Week52Low     *Decimal `json:"week52Low,omitempty"`
 }
 
 type QuoteBatch map[string][]Quote
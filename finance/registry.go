@@ -0,0 +1,103 @@
+package finance
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+var (
+	ErrUnknownProvider = fmt.Errorf("unknown finance provider")
+
+	providersMu sync.RWMutex
+	providers   = make(map[string]Backend)
+)
+
+// Config describes the source of a finance provider's settings. A
+// *viper.Viper satisfies this interface.
+type Config interface {
+	GetBool(key string) bool
+	GetDuration(key string) time.Duration
+	GetFloat64(key string) float64
+	GetInt(key string) int
+	GetInt64(key string) int64
+	GetString(key string) string
+	GetStringSlice(key string) []string
+	GetUint(key string) uint
+}
+
+// Backend describes a finance provider that can be selected by name at
+// runtime.
+type Backend struct {
+	// Flags adds the provider's settings to the given flag set. Flag names
+	// should be prefixed with the provider's name to avoid collisions. It may
+	// be nil if the provider has no settings of its own.
+	Flags func(fs *pflag.FlagSet)
+
+	// New returns a new Provider object configured from the given settings,
+	// which include the provider's flags. It's responsible for validating
+	// the provider's credentials.
+	New func(cfg Config) (Provider, error)
+}
+
+// Register makes a finance provider available by the given name. Providers
+// typically register themselves in an init function. Register panics if it's
+// called twice for the same name or if the backend's New function is nil.
+func Register(name string, b Backend) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	if b.New == nil {
+		panic(fmt.Sprintf("finance: nil New for provider %q", name))
+	}
+	if _, ok := providers[name]; ok {
+		panic(fmt.Sprintf("finance: provider %q registered twice", name))
+	}
+
+	providers[name] = b
+}
+
+// Providers returns the sorted names of the registered finance providers.
+func Providers() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// AddFlags adds the settings of every registered finance provider to the
+// given flag set.
+func AddFlags(fs *pflag.FlagSet) {
+	for _, name := range Providers() {
+		providersMu.RLock()
+		f := providers[name].Flags
+		providersMu.RUnlock()
+
+		if f != nil {
+			f(fs)
+		}
+	}
+}
+
+// New returns a new Provider object from the named finance provider,
+// configured from the given settings.
+func New(name string, cfg Config) (Provider, error) {
+	providersMu.RLock()
+	b, ok := providers[name]
+	providersMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownProvider, name)
+	}
+
+	return b.New(cfg)
+}
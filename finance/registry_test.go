@@ -0,0 +1,67 @@
+package finance
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+type testConfig map[string]string
+
+func (c testConfig) GetBool(string) bool              { return false }
+func (c testConfig) GetDuration(string) time.Duration { return 0 }
+func (c testConfig) GetFloat64(string) float64        { return 0 }
+func (c testConfig) GetInt(string) int                { return 0 }
+func (c testConfig) GetInt64(string) int64            { return 0 }
+func (c testConfig) GetString(key string) string      { return c[key] }
+func (c testConfig) GetStringSlice(string) []string   { return nil }
+func (c testConfig) GetUint(string) uint              { return 0 }
+
+func TestRegistry(t *testing.T) {
+	var configured string
+	Register("test", Backend{
+		Flags: func(fs *pflag.FlagSet) {
+			fs.String("test-setting", "default", "test setting")
+		},
+		New: func(cfg Config) (Provider, error) {
+			configured = cfg.GetString("test-setting")
+			return nil, nil
+		},
+	})
+
+	found := false
+	for _, name := range Providers() {
+		found = found || name == "test"
+	}
+	if !found {
+		t.Errorf("provider not listed: %v", Providers())
+	}
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	AddFlags(fs)
+	if f := fs.Lookup("test-setting"); f == nil || f.DefValue != "default" {
+		t.Errorf("provider flag not added: %v", f)
+	}
+
+	_, err := New("test", testConfig{"test-setting": "configured"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configured != "configured" {
+		t.Errorf("actual setting: %q; expected: %q", configured, "configured")
+	}
+
+	_, err = New("nonexistent", testConfig{})
+	if !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("expected ErrUnknownProvider; actual: %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a provider twice did not panic")
+		}
+	}()
+	Register("test", Backend{New: func(Config) (Provider, error) { return nil, nil }})
+}
@@ -0,0 +1,153 @@
+// Package replay provides a finance.Provider implementation that plays back
+// stock quotes recorded in a CSV or NDJSON file, allowing a recorded trading
+// day to be replayed through the application (e.g., to reproduce incidents
+// or for demos).
+//
+// CSV files must begin with a header row naming the symbol, price, and time
+// columns, in any order. NDJSON files contain one JSON-encoded quote per
+// line, in the same form the API returns them. Times are RFC 3339 formatted.
+//
+// Quotes are served in timestamp order. By default, each call to GetQuotes
+// advances playback to the next recorded timestamp. Given a speed, playback
+// instead advances with the clock: at a speed of 60, an hour of recorded
+// quotes plays back in a minute.
+package replay
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+)
+
+var (
+	_ finance.Provider = (*Client)(nil)
+
+	ErrEndOfReplay   = fmt.Errorf("end of replay")
+	ErrEmptyRecord   = fmt.Errorf("recording contains no quotes")
+	ErrInvalidSpeed  = fmt.Errorf("speed cannot be negative")
+	ErrUnknownFormat = fmt.Errorf("unknown format")
+)
+
+// Client plays back recorded stock quotes.
+type Client struct {
+	file   string
+	format Format
+	now    func() time.Time
+	speed  float64
+
+	mu     sync.Mutex
+	quotes []finance.Quote
+	next   int
+	latest map[string]finance.Quote
+	start  time.Time
+}
+
+// GetQuotes accepts one or more stock symbols, advances playback, and returns
+// the latest recorded quote for each stock as of the playback position.
+// Stocks without a recorded quote at or before the playback position are
+// omitted. It returns ErrEndOfReplay once every recorded quote has been
+// served.
+func (c *Client) GetQuotes(_ context.Context, symbols ...string) (
+	[]finance.Quote, error) {
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("empty symbols")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.next == len(c.quotes) {
+		return nil, ErrEndOfReplay
+	}
+
+	position := c.position()
+	for ; c.next < len(c.quotes) && !c.quotes[c.next].Time.After(position); c.next++ {
+		q := c.quotes[c.next]
+		c.latest[q.Symbol] = q
+	}
+
+	quotes := make([]finance.Quote, 0, len(symbols))
+	for _, symbol := range symbols {
+		if q, ok := c.latest[strings.ToLower(symbol)]; ok {
+			quotes = append(quotes, q)
+		}
+	}
+
+	return quotes, nil
+}
+
+// position returns the recorded time playback has reached.
+func (c *Client) position() time.Time {
+	if c.speed == 0 {
+		return c.quotes[c.next].Time
+	}
+
+	if c.start.IsZero() {
+		c.start = c.now()
+	}
+
+	elapsed := time.Duration(float64(c.now().Sub(c.start)) * c.speed)
+
+	return c.quotes[0].Time.Add(elapsed)
+}
+
+// New accepts the path to a recording and returns a pointer to a new Client
+// object after applying optional settings. The recording's format is
+// inferred from its extension (.csv, .ndjson, or .jsonl) unless specified.
+//
+// Defaults:
+//     Clock = time.Now
+//     Speed = 0 (advance one timestamp per call)
+func New(file string, options ...Option) (*Client, error) {
+	c := &Client{
+		file:   file,
+		latest: make(map[string]finance.Quote),
+		now:    time.Now,
+	}
+
+	for _, option := range options {
+		if option != nil {
+			option(c)
+		}
+	}
+
+	if c.speed < 0 {
+		return nil, ErrInvalidSpeed
+	}
+
+	if c.format == "" {
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".csv":
+			c.format = CSV
+		case ".ndjson", ".jsonl":
+			c.format = NDJSON
+		}
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	c.quotes, err = decode(f, c.format)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %q: %w", file, err)
+	}
+	if len(c.quotes) == 0 {
+		return nil, ErrEmptyRecord
+	}
+
+	sort.SliceStable(c.quotes, func(i, j int) bool {
+		return c.quotes[i].Time.Before(c.quotes[j].Time)
+	})
+
+	return c, nil
+}
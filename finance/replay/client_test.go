@@ -0,0 +1,190 @@
+package replay
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+)
+
+// dec parses a Decimal constant, such as a price.
+var dec = finance.MustParseDecimal
+
+const (
+	recordingCSV = `time,symbol,price
+2021-05-07T14:00:00Z,FB,320.12
+2021-05-07T14:00:00Z,goog,2402.14
+2021-05-07T14:01:00Z,fb,320.25
+2021-05-07T14:03:00Z,goog,2403.06
+`
+
+	// out of order, to verify playback sorts by time
+	recordingNDJSON = `{"price":320.25,"symbol":"fb","time":"2021-05-07T14:01:00Z"}
+{"price":320.12,"symbol":"FB","time":"2021-05-07T14:00:00Z"}
+{"price":2402.14,"symbol":"goog","time":"2021-05-07T14:00:00Z"}
+{"price":2403.06,"symbol":"goog","time":"2021-05-07T14:03:00Z"}
+`
+)
+
+var start = time.Date(2021, 5, 7, 14, 0, 0, 0, time.UTC)
+
+func writeRecording(t *testing.T, name, content string) string {
+	dir, err := ioutil.TempDir("", "stonks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Logf("removing temp dir: %v", err)
+		}
+	})
+
+	file := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+func TestNewClientErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(writeRecording(t, "quotes.txt", recordingCSV))
+	if err == nil {
+		t.Error("expected an unknown format error")
+	}
+
+	_, err = New(writeRecording(t, "quotes.csv", "time,symbol,price\n"))
+	if err != ErrEmptyRecord {
+		t.Errorf("expected ErrEmptyRecord; actual: %v", err)
+	}
+
+	_, err = New(writeRecording(t, "quotes.csv", "time,price\n"))
+	if err == nil {
+		t.Error("expected a missing column error")
+	}
+
+	_, err = New(writeRecording(t, "quotes.csv", recordingCSV), Speed(-1))
+	if err != ErrInvalidSpeed {
+		t.Errorf("expected ErrInvalidSpeed; actual: %v", err)
+	}
+}
+
+func TestGetQuotesStep(t *testing.T) {
+	t.Parallel()
+
+	expected := [][]finance.Quote{
+		{
+			{Price: dec("320.12"), Symbol: "fb", Time: start},
+			{Price: dec("2402.14"), Symbol: "goog", Time: start},
+		},
+		{
+			{Price: dec("320.25"), Symbol: "fb", Time: start.Add(time.Minute)},
+			{Price: dec("2402.14"), Symbol: "goog", Time: start},
+		},
+		{
+			{Price: dec("320.25"), Symbol: "fb", Time: start.Add(time.Minute)},
+			{Price: dec("2403.06"), Symbol: "goog", Time: start.Add(3 * time.Minute)},
+		},
+	}
+
+	for file, format := range map[string]Format{
+		writeRecording(t, "quotes.csv", recordingCSV):       "",
+		writeRecording(t, "quotes.ndjson", recordingNDJSON): "",
+		writeRecording(t, "quotes.log", recordingNDJSON):    NDJSON,
+	} {
+		c, err := New(file, FileFormat(format))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i, e := range expected {
+			actual, err := c.GetQuotes(context.Background(), "fb", "GOOG")
+			if err != nil {
+				t.Fatalf("%s: %d: %v", filepath.Base(file), i, err)
+			}
+
+			if !reflect.DeepEqual(actual, e) {
+				t.Errorf("%s: %d: actual quotes not equal to expected",
+					filepath.Base(file), i)
+				t.Logf("expected: %#v", e)
+				t.Logf("actual:   %#v", actual)
+			}
+		}
+
+		_, err = c.GetQuotes(context.Background(), "fb", "goog")
+		if err != ErrEndOfReplay {
+			t.Errorf("%s: expected ErrEndOfReplay; actual: %v",
+				filepath.Base(file), err)
+		}
+	}
+}
+
+func TestGetQuotesSpeed(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	c, err := New(
+		writeRecording(t, "quotes.csv", recordingCSV),
+		Clock(func() time.Time { return now }),
+		Speed(60), // a recorded minute per second
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		elapsed  time.Duration
+		expected []finance.Quote
+		err      error
+	}{
+		{
+			expected: []finance.Quote{
+				{Price: dec("320.12"), Symbol: "fb", Time: start},
+			},
+		},
+		{
+			elapsed: 2 * time.Second,
+			expected: []finance.Quote{
+				{Price: dec("320.25"), Symbol: "fb", Time: start.Add(time.Minute)},
+			},
+		},
+		{
+			elapsed: 500 * time.Millisecond,
+			expected: []finance.Quote{
+				{Price: dec("320.25"), Symbol: "fb", Time: start.Add(time.Minute)},
+			},
+		},
+		{
+			elapsed: time.Second,
+			expected: []finance.Quote{
+				{Price: dec("320.25"), Symbol: "fb", Time: start.Add(time.Minute)},
+			},
+		},
+		{
+			err: ErrEndOfReplay,
+		},
+	}
+
+	for i, tc := range testCases {
+		now = now.Add(tc.elapsed)
+
+		actual, err := c.GetQuotes(context.Background(), "fb")
+		if err != tc.err {
+			t.Errorf("%d: actual error: %v; expected: %v", i, err, tc.err)
+			continue
+		}
+
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("%d: actual quotes not equal to expected", i)
+			t.Logf("expected: %#v", tc.expected)
+			t.Logf("actual:   %#v", actual)
+		}
+	}
+}
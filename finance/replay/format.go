@@ -0,0 +1,124 @@
+package replay
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+)
+
+// Format is the encoding of a recording.
+type Format string
+
+const (
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+)
+
+// decode returns the quotes read from r in the given format, with lowercase
+// symbols.
+func decode(r io.Reader, f Format) ([]finance.Quote, error) {
+	var (
+		quotes []finance.Quote
+		err    error
+	)
+
+	switch f {
+	case CSV:
+		quotes, err = decodeCSV(r)
+	case NDJSON:
+		quotes, err = decodeNDJSON(r)
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownFormat, f)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range quotes {
+		quotes[i].Symbol = strings.ToLower(quotes[i].Symbol)
+	}
+
+	return quotes, nil
+}
+
+// decodeCSV reads quotes from CSV with a header row naming the symbol, price,
+// and time columns.
+func decodeCSV(r io.Reader) ([]finance.Quote, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+
+	columns := map[string]int{"symbol": -1, "price": -1, "time": -1}
+	for i, name := range header {
+		if _, ok := columns[strings.ToLower(name)]; ok {
+			columns[strings.ToLower(name)] = i
+		}
+	}
+	for name, i := range columns {
+		if i < 0 {
+			return nil, fmt.Errorf("header missing %q column", name)
+		}
+	}
+
+	var quotes []finance.Quote
+	for row := 2; ; row++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		price, err := finance.ParseDecimal(record[columns["price"]])
+		if err != nil {
+			return nil, fmt.Errorf("row %d: price: %w", row, err)
+		}
+
+		t, err := time.Parse(time.RFC3339Nano, record[columns["time"]])
+		if err != nil {
+			return nil, fmt.Errorf("row %d: time: %w", row, err)
+		}
+
+		quotes = append(quotes, finance.Quote{
+			Price:  price,
+			Symbol: record[columns["symbol"]],
+			Time:   t,
+		})
+	}
+
+	return quotes, nil
+}
+
+// decodeNDJSON reads quotes from newline-delimited JSON.
+func decodeNDJSON(r io.Reader) ([]finance.Quote, error) {
+	var (
+		d      = json.NewDecoder(r)
+		quotes []finance.Quote
+	)
+
+	for {
+		var q finance.Quote
+
+		err := d.Decode(&q)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("quote %d: %w", len(quotes)+1, err)
+		}
+
+		quotes = append(quotes, q)
+	}
+
+	return quotes, nil
+}
@@ -0,0 +1,34 @@
+package replay
+
+import (
+	"strings"
+	"time"
+)
+
+type Option func(*Client)
+
+// Clock sets the function playback uses to tell the time when it's advanced
+// at a given speed. Tests can use a fake clock to control playback.
+func Clock(now func() time.Time) Option {
+	return func(c *Client) {
+		if now != nil {
+			c.now = now
+		}
+	}
+}
+
+// FileFormat sets the recording's format, overriding the format inferred
+// from its file extension.
+func FileFormat(f Format) Option {
+	return func(c *Client) {
+		c.format = Format(strings.ToLower(string(f)))
+	}
+}
+
+// Speed sets the playback speed as a multiple of real time. A speed of 0
+// advances playback to the next recorded timestamp on each call instead.
+func Speed(speed float64) Option {
+	return func(c *Client) {
+		c.speed = speed
+	}
+}
@@ -0,0 +1,23 @@
+package replay
+
+import (
+	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/spf13/pflag"
+)
+
+func init() {
+	finance.Register("replay", finance.Backend{
+		Flags: func(fs *pflag.FlagSet) {
+			fs.String("replay-file", "", "recorded quotes file to replay")
+			fs.String("replay-format", "", "recorded quotes format: csv or ndjson (default inferred from extension)")
+			fs.Float64("replay-speed", 0, "playback speed multiple; 0 advances one timestamp per poll")
+		},
+		New: func(cfg finance.Config) (finance.Provider, error) {
+			return New(
+				cfg.GetString("replay-file"),
+				FileFormat(Format(cfg.GetString("replay-format"))),
+				Speed(cfg.GetFloat64("replay-speed")),
+			)
+		},
+	})
+}
@@ -0,0 +1,181 @@
+// Package simulated provides a finance.Provider implementation that generates
+// stock quotes from a simulated market, allowing the application to run
+// without access to a real financial data service (e.g., in CI or offline).
+//
+// Each symbol's price follows its own geometric Brownian motion, advancing
+// one step each time the symbol is quoted. Each symbol's random source is
+// derived from the seed and the symbol, so a given seed always produces the
+// same sequence of prices per symbol, regardless of the order or combination
+// in which symbols are requested.
+package simulated
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+)
+
+const (
+	// DefaultDrift is the default annualized drift (expected return) of each
+	// symbol's price.
+	DefaultDrift = 0.05
+
+	// DefaultSeed is the default seed for the simulation's random sources.
+	DefaultSeed = 1
+
+	// DefaultStartPrice is the default price of each symbol's first quote.
+	DefaultStartPrice = 100.0
+
+	// DefaultStep is the default duration of simulated market time that
+	// passes between each symbol's consecutive quotes.
+	DefaultStep = time.Minute
+
+	// DefaultTickSize is the default minimum price increment.
+	DefaultTickSize = 0.01
+
+	// DefaultVolatility is the default annualized volatility of each
+	// symbol's price.
+	DefaultVolatility = 0.2
+
+	// year is the duration used to scale the annualized drift and volatility
+	// to a single step.
+	year = 365.25 * 24 * time.Hour
+)
+
+var (
+	_ finance.Provider = (*Client)(nil)
+
+	ErrInvalidStartPrice = fmt.Errorf("start price must be positive")
+	ErrInvalidTickSize   = fmt.Errorf("tick size must be at least 0.000001")
+)
+
+// Client is a simulated market.
+type Client struct {
+	drift      float64
+	now        func() time.Time
+	seed       int64
+	startPrice float64
+	step       time.Duration
+	tickSize   float64
+	volatility float64
+
+	mu    sync.Mutex
+	walks map[string]*walk
+}
+
+// walk is the state of a single symbol's random walk.
+type walk struct {
+	rng   *rand.Rand
+	price float64
+	moved bool
+}
+
+// GetQuotes accepts one or more stock symbols and returns the next simulated
+// quote for each stock.
+func (c *Client) GetQuotes(_ context.Context, symbols ...string) (
+	[]finance.Quote, error) {
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("empty symbols")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	quotes := make([]finance.Quote, 0, len(symbols))
+
+	for _, symbol := range symbols {
+		symbol = strings.ToLower(symbol)
+
+		quotes = append(quotes, finance.Quote{
+			Price:  c.roundToTick(c.next(symbol)),
+			Symbol: symbol,
+			Time:   now,
+		})
+	}
+
+	return quotes, nil
+}
+
+// next advances the symbol's random walk by one step and returns its price.
+// The first call for a symbol returns the start price.
+func (c *Client) next(symbol string) float64 {
+	w, ok := c.walks[symbol]
+	if !ok {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(symbol))
+
+		w = &walk{
+			rng:   rand.New(rand.NewSource(c.seed ^ int64(h.Sum64()))),
+			price: c.startPrice,
+		}
+		c.walks[symbol] = w
+	}
+
+	if !w.moved {
+		w.moved = true
+		return w.price
+	}
+
+	// S(t+dt) = S(t) * exp((mu - sigma^2/2)dt + sigma * sqrt(dt) * Z)
+	dt := float64(c.step) / float64(year)
+	w.price *= math.Exp((c.drift-c.volatility*c.volatility/2)*dt +
+		c.volatility*math.Sqrt(dt)*w.rng.NormFloat64())
+
+	return w.price
+}
+
+// roundToTick rounds the price to the nearest tick, never rounding a price
+// down to zero.
+func (c *Client) roundToTick(price float64) finance.Decimal {
+	tick := finance.NewDecimal(c.tickSize)
+	ticks := math.Max(math.Round(price/tick.Float64()), 1)
+
+	return finance.Decimal(ticks) * tick
+}
+
+// New returns a pointer to a new Client object after applying optional
+// settings.
+//
+// Defaults:
+//     Clock      = time.Now
+//     Drift      = 0.05
+//     Seed       = 1
+//     StartPrice = 100.0
+//     Step       = time.Minute
+//     TickSize   = 0.01
+//     Volatility = 0.2
+func New(options ...Option) (*Client, error) {
+	c := &Client{
+		drift:      DefaultDrift,
+		now:        time.Now,
+		seed:       DefaultSeed,
+		startPrice: DefaultStartPrice,
+		step:       DefaultStep,
+		tickSize:   DefaultTickSize,
+		volatility: DefaultVolatility,
+		walks:      make(map[string]*walk),
+	}
+
+	for _, option := range options {
+		if option != nil {
+			option(c)
+		}
+	}
+
+	switch {
+	case c.startPrice <= 0:
+		return nil, ErrInvalidStartPrice
+	case finance.NewDecimal(c.tickSize) <= 0:
+		return nil, ErrInvalidTickSize
+	}
+
+	return c, nil
+}
@@ -0,0 +1,158 @@
+package simulated
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+)
+
+func TestNewClientDefaults(t *testing.T) {
+	t.Parallel()
+
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.drift != DefaultDrift || c.volatility != DefaultVolatility {
+		t.Errorf("unexpected drift and volatility: %f, %f", c.drift,
+			c.volatility)
+	}
+	if c.seed != DefaultSeed || c.startPrice != DefaultStartPrice {
+		t.Errorf("unexpected seed and start price: %d, %f", c.seed,
+			c.startPrice)
+	}
+	if c.step != DefaultStep || c.tickSize != DefaultTickSize {
+		t.Errorf("unexpected step and tick size: %s, %f", c.step, c.tickSize)
+	}
+
+	_, err = New(StartPrice(0))
+	if err != ErrInvalidStartPrice {
+		t.Errorf("expected ErrInvalidStartPrice; actual: %v", err)
+	}
+
+	_, err = New(TickSize(-1))
+	if err != ErrInvalidTickSize {
+		t.Errorf("expected ErrInvalidTickSize; actual: %v", err)
+	}
+}
+
+func TestGetQuotesDeterministic(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2021, 5, 7, 19, 30, 0, 0, time.UTC)
+	quotes := func(symbols ...string) []finance.Quote {
+		c, err := New(Seed(42), Clock(func() time.Time { return now }))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var out []finance.Quote
+		for i := 0; i < 50; i++ {
+			q, err := c.GetQuotes(context.Background(), symbols...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out = append(out, q...)
+		}
+
+		return out
+	}
+
+	a, b := quotes("fb", "goog"), quotes("FB", "goog")
+	if !reflect.DeepEqual(a, b) {
+		t.Error("the same seed produced different quotes")
+	}
+
+	// A symbol's prices don't depend on the other symbols requested.
+	var fb []finance.Quote
+	for _, q := range a {
+		if q.Symbol == "fb" {
+			fb = append(fb, q)
+		}
+	}
+	if !reflect.DeepEqual(fb, quotes("fb")) {
+		t.Error("fb's prices depend on the other requested symbols")
+	}
+
+	start := finance.NewDecimal(DefaultStartPrice)
+	if a[0].Price != start || a[1].Price != start {
+		t.Errorf("first prices: %s, %s; expected: %s", a[0].Price,
+			a[1].Price, start)
+	}
+	if a[0].Time != now {
+		t.Errorf("actual time: %s; expected: %s", a[0].Time, now)
+	}
+
+	moved := false
+	for _, q := range a {
+		moved = moved || q.Price != start
+		if q.Price <= 0 {
+			t.Errorf("non-positive price: %s", q.Price)
+		}
+		if q.Price%finance.NewDecimal(DefaultTickSize) != 0 {
+			t.Errorf("price %s is not a multiple of the tick size", q.Price)
+		}
+	}
+	if !moved {
+		t.Error("prices never moved")
+	}
+
+	c, err := New(Seed(43))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		q, err := c.GetQuotes(context.Background(), "fb")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 1 && q[0].Price == a[2].Price {
+			t.Error("different seeds produced the same price")
+		}
+	}
+}
+
+func TestRoundToTick(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		tick, price float64
+		expected    string
+	}{
+		{tick: 0.01, price: 100.004, expected: "100"},
+		{tick: 0.01, price: 123.4251, expected: "123.43"},
+		{tick: 0.05, price: 10.02, expected: "10"},
+		{tick: 0.05, price: 10.03, expected: "10.05"},
+		{tick: 5, price: 12, expected: "10"},
+		{tick: 0.01, price: 0.001, expected: "0.01"},
+	}
+
+	for i, tc := range testCases {
+		c, err := New(TickSize(tc.tick))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual := c.roundToTick(tc.price).String(); actual != tc.expected {
+			t.Errorf("%d: actual: %v; expected: %v", i, actual, tc.expected)
+		}
+	}
+}
+
+func TestGetQuotesEmptySymbols(t *testing.T) {
+	t.Parallel()
+
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.GetQuotes(context.Background())
+	if err == nil {
+		t.Error("expected an error for empty symbols")
+	}
+}
@@ -0,0 +1,62 @@
+package simulated
+
+import "time"
+
+type Option func(*Client)
+
+// Clock sets the function used to timestamp quotes. Tests can use a fixed
+// clock to make quotes entirely deterministic.
+func Clock(now func() time.Time) Option {
+	return func(c *Client) {
+		if now != nil {
+			c.now = now
+		}
+	}
+}
+
+// Drift sets the annualized drift (expected return) of each symbol's price.
+func Drift(drift float64) Option {
+	return func(c *Client) {
+		c.drift = drift
+	}
+}
+
+// Seed sets the seed for the simulation's random sources.
+func Seed(seed int64) Option {
+	return func(c *Client) {
+		c.seed = seed
+	}
+}
+
+// StartPrice sets the price of each symbol's first quote.
+func StartPrice(price float64) Option {
+	return func(c *Client) {
+		c.startPrice = price
+	}
+}
+
+// Step sets the duration of simulated market time that passes between each
+// symbol's consecutive quotes.
+func Step(d time.Duration) Option {
+	return func(c *Client) {
+		if d > 0 {
+			c.step = d
+		}
+	}
+}
+
+// TickSize sets the minimum price increment.
+func TickSize(size float64) Option {
+	return func(c *Client) {
+		c.tickSize = size
+	}
+}
+
+// Volatility sets the annualized volatility of each symbol's price.
+func Volatility(volatility float64) Option {
+	return func(c *Client) {
+		if volatility >= 0 {
+			c.volatility = volatility
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package simulated
+
+import (
+	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/spf13/pflag"
+)
+
+func init() {
+	finance.Register("simulated", finance.Backend{
+		Flags: func(fs *pflag.FlagSet) {
+			fs.Float64("simulated-drift", DefaultDrift, "annualized drift of simulated prices")
+			fs.Int64("simulated-seed", DefaultSeed, "seed for simulated prices")
+			fs.Float64("simulated-start-price", DefaultStartPrice, "first simulated price of each symbol")
+			fs.Duration("simulated-step", DefaultStep, "simulated market time between quotes")
+			fs.Float64("simulated-tick-size", DefaultTickSize, "minimum simulated price increment")
+			fs.Float64("simulated-volatility", DefaultVolatility, "annualized volatility of simulated prices")
+		},
+		New: func(cfg finance.Config) (finance.Provider, error) {
+			return New(
+				Drift(cfg.GetFloat64("simulated-drift")),
+				Seed(cfg.GetInt64("simulated-seed")),
+				StartPrice(cfg.GetFloat64("simulated-start-price")),
+				Step(cfg.GetDuration("simulated-step")),
+				TickSize(cfg.GetFloat64("simulated-tick-size")),
+				Volatility(cfg.GetFloat64("simulated-volatility")),
+			)
+		},
+	})
+}
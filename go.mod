@@ -5,10 +5,12 @@ go 1.16
 require (
 	github.com/NYTimes/gziphandler v1.1.1
 	github.com/gorilla/mux v1.8.0
+	github.com/gorilla/websocket v1.4.2
 	github.com/influxdata/influxdb-client-go/v2 v2.2.3
 	github.com/mattn/go-sqlite3 v1.14.7
 	github.com/prometheus/client_golang v0.9.3
 	github.com/spf13/cobra v1.1.3
+	github.com/spf13/pflag v1.0.5
 	github.com/spf13/viper v1.7.0
 	go.uber.org/multierr v1.6.0
 	go.uber.org/zap v1.16.0
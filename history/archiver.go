@@ -2,14 +2,38 @@ package history
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 
 	"github.com/awoodbeck/faang-stonks/finance"
+	"go.uber.org/multierr"
 )
 
+// ErrRejected is wrapped by archivers that refuse quotes for good, such as
+// quotes for a symbol they don't track, so archiving them again won't help.
+var ErrRejected = fmt.Errorf("quotes rejected")
+
 // Archiver describes an object that can archive or store stock prices.
 type Archiver interface {
 	// SetQuotes accepts a slice of finance.Quote objects and archives them.
 	SetQuotes(ctx context.Context, quotes []finance.Quote) error
 	io.Closer
 }
+
+// Rejected returns true if err, or every error combined in it, wraps
+// ErrRejected. An error that combines a rejection with any other failure
+// isn't a rejection, since archiving the quotes again may yet succeed.
+func Rejected(err error) bool {
+	errs := multierr.Errors(err)
+	if len(errs) == 0 {
+		return false
+	}
+	for _, err := range errs {
+		if !errors.Is(err, ErrRejected) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,30 @@
+package history
+
+import (
+	"fmt"
+	"testing"
+
+	"go.uber.org/multierr"
+)
+
+func TestRejected(t *testing.T) {
+	t.Parallel()
+
+	rejected := fmt.Errorf("symbol %q not found: %w", "tsla", ErrRejected)
+	outage := fmt.Errorf("outage")
+
+	for i, tc := range []struct {
+		err      error
+		expected bool
+	}{
+		{err: nil},
+		{err: outage},
+		{err: rejected, expected: true},
+		{err: multierr.Combine(rejected, rejected), expected: true},
+		{err: multierr.Combine(rejected, outage)},
+	} {
+		if actual := Rejected(tc.err); actual != tc.expected {
+			t.Errorf("%d: actual: %t; expected: %t", i, actual, tc.expected)
+		}
+	}
+}
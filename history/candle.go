@@ -0,0 +1,47 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+)
+
+var ErrInvalidInterval = fmt.Errorf("invalid interval")
+
+// Candle summarizes a stock's quotes over an interval of time. Time marks the
+// start of the interval, aligned to a multiple of the interval since the Unix
+// epoch.
+type Candle struct {
+	Symbol string          `json:"symbol"`
+	Time   time.Time       `json:"time"`
+	Open   finance.Decimal `json:"open"`
+	High   finance.Decimal `json:"high"`
+	Low    finance.Decimal `json:"low"`
+	Close  finance.Decimal `json:"close"`
+	Count  int             `json:"count"`
+}
+
+// CandleProvider describes an object that can aggregate archived quotes into
+// open/high/low/close candles.
+type CandleProvider interface {
+	// GetCandles accepts a context for cancellation support, a stock symbol,
+	// an interval, and a range. It aggregates the stock's quotes that fall
+	// within the range into candles of the given interval, returning up to
+	// r.Limit candles in the requested order, or ErrNotFound if no quotes
+	// fall within the range.
+	GetCandles(ctx context.Context, symbol string, interval time.Duration,
+		r Range) ([]Candle, error)
+}
+
+// ValidateInterval returns ErrInvalidInterval unless the candle interval is a
+// positive number of whole seconds.
+func ValidateInterval(interval time.Duration) error {
+	if interval < time.Second || interval%time.Second != 0 {
+		return fmt.Errorf("%w: %s is not a positive number of whole seconds",
+			ErrInvalidInterval, interval)
+	}
+
+	return nil
+}
@@ -0,0 +1,324 @@
+// Package fanout provides a history.Storage implementation that archives each
+// batch of quotes to several other archivers at once.
+//
+// Each target is either required or best-effort. SetQuotes writes to the
+// required targets concurrently and fails if any of them fails. It queues
+// the quotes for each best-effort target instead of waiting on it, and only
+// counts their failures, so a slow or failing secondary store, such as an
+// InfluxDB bucket feeding dashboards, can't hold up archiving. Reads come from
+// the primary target: the first required target.
+package fanout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/awoodbeck/faang-stonks/history"
+	"github.com/awoodbeck/faang-stonks/metrics"
+	"go.uber.org/multierr"
+)
+
+const (
+	// DefaultBestEffortQueue is the default number of batches of quotes
+	// queued for each best-effort target.
+	DefaultBestEffortQueue = 16
+
+	// DefaultBestEffortTimeout is the default duration a best-effort target
+	// has to archive a batch of quotes.
+	DefaultBestEffortTimeout = 10 * time.Second
+)
+
+var (
+	_ history.Storage = (*Client)(nil)
+
+	ErrNoPrimary = fmt.Errorf("no required target provides quotes")
+)
+
+// Policy describes how a target's failure affects archiving.
+type Policy int
+
+const (
+	// Required targets must archive every batch of quotes.
+	Required Policy = iota
+
+	// BestEffort targets may fail to archive a batch of quotes without
+	// failing the write.
+	BestEffort
+)
+
+// String returns the name of the policy.
+func (p Policy) String() string {
+	switch p {
+	case Required:
+		return "required"
+	case BestEffort:
+		return "best-effort"
+	default:
+		return fmt.Sprintf("Policy(%d)", int(p))
+	}
+}
+
+// Target pairs an archiver with its name and policy.
+type Target struct {
+	Name     string
+	Archiver history.Archiver
+	Policy   Policy
+}
+
+// Client archives quotes to each of its targets and reads quotes from its
+// primary target.
+type Client struct {
+	history.Provider
+	primary int
+	targets []Target
+
+	queueSize int
+	timeout   time.Duration
+
+	mu      sync.RWMutex
+	closed  bool
+	queues  []chan []finance.Quote // one per best-effort target, else nil
+	writers sync.WaitGroup
+}
+
+// Close waits for the best-effort targets to archive their queued quotes,
+// then closes every target, returning their errors combined.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	for _, q := range c.queues {
+		if q != nil {
+			close(q)
+		}
+	}
+	c.mu.Unlock()
+	c.writers.Wait()
+
+	var errs error
+	for _, t := range c.targets {
+		if err := t.Archiver.Close(); err != nil {
+			multierr.AppendInto(&errs, fmt.Errorf("%s: %w", t.Name, err))
+		}
+	}
+
+	return errs
+}
+
+// SetQuotes archives the quotes to the required targets concurrently and
+// waits for each to finish, returning the errors of those that failed,
+// combined. It queues the quotes for each best-effort target without waiting.
+// Best-effort failures, including batches dropped because a target's queue is
+// full, increment the fanout_target_failures_total metric alone.
+func (c *Client) SetQuotes(ctx context.Context, quotes []finance.Quote) error {
+	c.mu.RLock()
+	if c.closed {
+		c.mu.RUnlock()
+		return fmt.Errorf("fan-out archiver closed")
+	}
+	for i, q := range c.queues {
+		if q == nil {
+			continue
+		}
+		select {
+		case q <- quotes:
+		default:
+			c.failed(c.targets[i])
+		}
+	}
+	c.mu.RUnlock()
+
+	errs := make([]error, len(c.targets))
+
+	var wg sync.WaitGroup
+	for i, t := range c.targets {
+		if t.Policy != Required {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, t Target) {
+			defer wg.Done()
+
+			if err := t.Archiver.SetQuotes(ctx, quotes); err != nil {
+				c.failed(t)
+				errs[i] = fmt.Errorf("%s: %w", t.Name, err)
+			}
+		}(i, t)
+	}
+	wg.Wait()
+
+	return multierr.Combine(errs...)
+}
+
+// failed counts a batch of quotes the target failed to archive.
+func (c *Client) failed(t Target) {
+	metrics.FanoutFailures.WithLabelValues(t.Name, t.Policy.String()).Inc()
+}
+
+// write archives the batches of quotes queued for the best-effort target, in
+// order, until the queue is closed. Each batch has the timeout to archive,
+// independent of the caller's context.
+func (c *Client) write(t Target, queue <-chan []finance.Quote) {
+	defer c.writers.Done()
+
+	for quotes := range queue {
+		ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		if err := t.Archiver.SetQuotes(ctx, quotes); err != nil {
+			c.failed(t)
+		}
+		cancel()
+	}
+}
+
+// Storage returns the client as a history.Storage that also satisfies the
+// history.CandleProvider and history.SymbolRegistry interfaces if the primary
+// target does. Symbols added or removed through the registry are added to or
+// removed from every target that keeps a registry, so they all accept the
+// same quotes.
+func (c *Client) Storage() history.Storage {
+	cp, candles := c.Provider.(history.CandleProvider)
+	_, registry := c.Provider.(history.SymbolRegistry)
+
+	switch {
+	case candles && registry:
+		return struct {
+			symbolRegistry
+			history.CandleProvider
+		}{symbolRegistry{c}, cp}
+	case candles:
+		return struct {
+			*Client
+			history.CandleProvider
+		}{c, cp}
+	case registry:
+		return symbolRegistry{c}
+	default:
+		return c
+	}
+}
+
+// symbolRegistry extends a client whose primary target keeps a registry to
+// satisfy history.SymbolRegistry.
+type symbolRegistry struct {
+	*Client
+}
+
+// AddSymbol adds the symbol to the primary target's registry and then to
+// every other target's registry.
+func (s symbolRegistry) AddSymbol(ctx context.Context, symbol string) error {
+	err := s.Provider.(history.SymbolRegistry).AddSymbol(ctx, symbol)
+	if err != nil {
+		return err
+	}
+
+	return s.secondaries(func(r history.SymbolRegistry) error {
+		return r.AddSymbol(ctx, symbol)
+	})
+}
+
+// RemoveSymbol removes the symbol from the primary target's registry and then
+// from every other target's registry that tracks it.
+func (s symbolRegistry) RemoveSymbol(ctx context.Context, symbol string) error {
+	err := s.Provider.(history.SymbolRegistry).RemoveSymbol(ctx, symbol)
+	if err != nil {
+		return err
+	}
+
+	return s.secondaries(func(r history.SymbolRegistry) error {
+		if err := r.RemoveSymbol(ctx, symbol); !errors.Is(err, history.ErrNotFound) {
+			return err
+		}
+		return nil
+	})
+}
+
+// Symbols returns the primary target's symbols.
+func (s symbolRegistry) Symbols(ctx context.Context) ([]string, error) {
+	return s.Provider.(history.SymbolRegistry).Symbols(ctx)
+}
+
+// secondaries calls f for each target other than the primary that keeps a
+// registry, returning their errors combined.
+func (s symbolRegistry) secondaries(f func(history.SymbolRegistry) error) error {
+	var errs error
+	for i, t := range s.targets {
+		if i == s.primary {
+			continue
+		}
+		r, ok := t.Archiver.(history.SymbolRegistry)
+		if !ok {
+			continue
+		}
+		if err := f(r); err != nil {
+			multierr.AppendInto(&errs, fmt.Errorf("%s: %w", t.Name, err))
+		}
+	}
+
+	return errs
+}
+
+// New accepts the targets and returns a pointer to a new Client object after
+// applying optional settings. The first required target must also be a
+// history.Provider, which serves reads.
+//
+// Defaults:
+//     BestEffortQueue   = 16
+//     BestEffortTimeout = 10 * time.Second
+func New(targets []Target, options ...Option) (*Client, error) {
+	c := &Client{
+		queueSize: DefaultBestEffortQueue,
+		timeout:   DefaultBestEffortTimeout,
+	}
+
+	for _, option := range options {
+		if option != nil {
+			option(c)
+		}
+	}
+
+	for i, t := range targets {
+		if t.Archiver == nil {
+			return nil, fmt.Errorf("target %q is nil", t.Name)
+		}
+		switch t.Policy {
+		case Required, BestEffort:
+		default:
+			return nil, fmt.Errorf("target %q: unknown policy %s", t.Name, t.Policy)
+		}
+
+		if c.Provider == nil && t.Policy == Required {
+			p, ok := t.Archiver.(history.Provider)
+			if !ok {
+				return nil, fmt.Errorf("%w: target %q", ErrNoPrimary, t.Name)
+			}
+			c.Provider, c.primary = p, i
+		}
+
+		c.targets = append(c.targets, t)
+	}
+
+	if c.Provider == nil {
+		return nil, ErrNoPrimary
+	}
+
+	c.queues = make([]chan []finance.Quote, len(c.targets))
+	for i, t := range c.targets {
+		if t.Policy != BestEffort {
+			continue
+		}
+
+		c.queues[i] = make(chan []finance.Quote, c.queueSize)
+		c.writers.Add(1)
+		go c.write(t, c.queues[i])
+	}
+
+	return c, nil
+}
@@ -0,0 +1,305 @@
+package fanout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/awoodbeck/faang-stonks/history"
+	"github.com/awoodbeck/faang-stonks/history/memory"
+	"go.uber.org/multierr"
+)
+
+// dec parses a Decimal constant, such as a price.
+var dec = finance.MustParseDecimal
+
+var errOutage = fmt.Errorf("outage")
+
+// stubArchiver counts the batches it archives, or fails while it's down. If
+// ready is set, its first write waits for every stub sharing the wait group
+// to be called before it archives.
+type stubArchiver struct {
+	mu      sync.Mutex
+	batches int
+	closed  bool
+	down    bool
+	once    sync.Once
+	ready   *sync.WaitGroup
+}
+
+func (s *stubArchiver) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+
+	return nil
+}
+
+func (s *stubArchiver) SetQuotes(context.Context, []finance.Quote) error {
+	if s.ready != nil {
+		s.once.Do(func() {
+			s.ready.Done()
+			s.ready.Wait()
+		})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.down {
+		return errOutage
+	}
+	s.batches++
+
+	return nil
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		targets []Target
+		err     error
+	}{
+		{targets: nil, err: ErrNoPrimary},
+		{targets: []Target{{Name: "memory", Archiver: m, Policy: BestEffort}},
+			err: ErrNoPrimary},
+		{targets: []Target{{Name: "stub", Archiver: new(stubArchiver)},
+			{Name: "memory", Archiver: m}}, err: ErrNoPrimary},
+		{targets: []Target{{Name: "memory", Archiver: m},
+			{Name: "stub", Archiver: new(stubArchiver)}}},
+		{targets: []Target{{Name: "stub", Archiver: new(stubArchiver),
+			Policy: BestEffort}, {Name: "memory", Archiver: m}}},
+	}
+
+	for i, tc := range testCases {
+		_, err := New(tc.targets)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%d: actual error: %v; expected: %v", i, err, tc.err)
+		}
+	}
+
+	if _, err = New([]Target{{Name: "nil"}}); err == nil {
+		t.Error("expected an error for a nil archiver")
+	}
+	if _, err = New([]Target{{Name: "memory", Archiver: m, Policy: 2}}); err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+}
+
+func TestSetQuotes(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	quote := finance.Quote{Price: dec("123.45"), Symbol: "fb", Time: time.Now()}
+
+	primary, err := memory.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each stub waits for the others, so the write only completes if the
+	// targets are written to concurrently.
+	var ready sync.WaitGroup
+	ready.Add(3)
+	required := &stubArchiver{ready: &ready}
+	bestEffort := &stubArchiver{ready: &ready, down: true}
+	secondary := &stubArchiver{ready: &ready}
+
+	c, err := New([]Target{
+		{Name: "best-effort", Archiver: bestEffort, Policy: BestEffort},
+		{Name: "primary", Archiver: primary},
+		{Name: "required", Archiver: required},
+		{Name: "secondary", Archiver: secondary, Policy: BestEffort},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() { done <- c.SetQuotes(ctx, []finance.Quote{quote}) }()
+	select {
+	case err = <-done:
+	case <-time.After(time.Second):
+		t.Fatal("targets were not written to concurrently")
+	}
+
+	// The best-effort failure doesn't fail the write.
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if required.batches != 1 {
+		t.Errorf("actual required batches: %d; expected: 1", required.batches)
+	}
+
+	// Reads come from the primary.
+	actual, err := c.GetQuotes(ctx, "fb", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, []finance.Quote{quote}) {
+		t.Errorf("actual quotes: %#v", actual)
+	}
+
+	// Required failures are combined.
+	required.down = true
+	quote.Time = quote.Time.Add(time.Minute)
+	err = c.SetQuotes(ctx, []finance.Quote{quote})
+	if errs := multierr.Errors(err); len(errs) != 1 || !errors.Is(errs[0], errOutage) {
+		t.Errorf("expected the required target's error alone; actual: %v", err)
+	}
+
+	// Closing waits for the best-effort targets' queued quotes.
+	if err = c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if secondary.batches != 2 {
+		t.Errorf("actual secondary batches: %d; expected: 2", secondary.batches)
+	}
+	for _, s := range []*stubArchiver{required, bestEffort, secondary} {
+		if !s.closed {
+			t.Error("target not closed")
+		}
+	}
+	if err = c.SetQuotes(ctx, []finance.Quote{quote}); err == nil {
+		t.Error("expected an error archiving to a closed client")
+	}
+}
+
+// blockingArchiver blocks each write until its context is done.
+type blockingArchiver struct {
+	stubArchiver
+	canceled chan error
+}
+
+func (b *blockingArchiver) SetQuotes(ctx context.Context, _ []finance.Quote) error {
+	<-ctx.Done()
+	b.canceled <- ctx.Err()
+
+	return ctx.Err()
+}
+
+func TestSetQuotesBestEffortBlocking(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	primary, err := memory.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	slow := &blockingArchiver{canceled: make(chan error, 3)}
+
+	c, err := New([]Target{
+		{Name: "primary", Archiver: primary},
+		{Name: "slow", Archiver: slow, Policy: BestEffort},
+	}, BestEffortQueue(1), BestEffortTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The slow target neither holds up archiving nor fails it, even once
+	// its queue is full.
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		err = c.SetQuotes(ctx, []finance.Quote{{Price: dec("123.45"),
+			Symbol: "fb", Time: start.Add(time.Duration(i) * time.Minute)}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("archiving took %s", elapsed)
+	}
+
+	// Each queued write gives up after the timeout.
+	select {
+	case err = <-slow.canceled:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("actual error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("best-effort write did not time out")
+	}
+
+	if err = c.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStorage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	primary, err := memory.New(memory.Symbols([]string{"fb"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondary, err := memory.New(memory.Symbols([]string{"fb"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := New([]Target{
+		{Name: "primary", Archiver: primary},
+		{Name: "secondary", Archiver: secondary, Policy: BestEffort},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := c.Storage()
+
+	if _, ok := s.(history.CandleProvider); !ok {
+		t.Error("storage is not a candle provider")
+	}
+	r, ok := s.(history.SymbolRegistry)
+	if !ok {
+		t.Fatal("storage is not a symbol registry")
+	}
+
+	// Symbols reach every target's registry.
+	if err = r.AddSymbol(ctx, "goog"); err != nil {
+		t.Fatal(err)
+	}
+	if err = secondary.RemoveSymbol(ctx, "fb"); err != nil {
+		t.Fatal(err)
+	}
+	if err = r.RemoveSymbol(ctx, "fb"); err != nil {
+		t.Errorf("removing a symbol a secondary doesn't track: %v", err)
+	}
+	for _, m := range []*memory.Client{primary, secondary} {
+		symbols, err := m.Symbols(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := []string{"goog"}; !reflect.DeepEqual(symbols, expected) {
+			t.Errorf("actual symbols: %v; expected: %v", symbols, expected)
+		}
+	}
+
+	// A primary without optional interfaces leaves them off.
+	c, err = New([]Target{{Name: "primary", Archiver: struct {
+		history.Storage
+	}{primary}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s = c.Storage()
+	if _, ok = s.(history.CandleProvider); ok {
+		t.Error("storage is unexpectedly a candle provider")
+	}
+	if _, ok = s.(history.SymbolRegistry); ok {
+		t.Error("storage is unexpectedly a symbol registry")
+	}
+}
@@ -0,0 +1,25 @@
+package fanout
+
+import "time"
+
+type Option func(*Client)
+
+// BestEffortQueue sets the number of batches of quotes queued for each
+// best-effort target. Batches that don't fit are dropped.
+func BestEffortQueue(n int) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.queueSize = n
+		}
+	}
+}
+
+// BestEffortTimeout sets the duration a best-effort target has to archive a
+// batch of quotes.
+func BestEffortTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		if d > 0 {
+			c.timeout = d
+		}
+	}
+}
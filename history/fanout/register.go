@@ -0,0 +1,74 @@
+package fanout
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awoodbeck/faang-stonks/history"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+func init() {
+	history.Register("fanout", history.Backend{
+		Flags: func(fs *pflag.FlagSet) {
+			fs.StringSlice("fanout-best-effort", nil, "storage backends that may fail to archive quotes")
+			fs.Int("fanout-best-effort-queue", DefaultBestEffortQueue, "batches of quotes queued for each best-effort backend before dropping")
+			fs.Duration("fanout-best-effort-timeout", DefaultBestEffortTimeout, "duration a best-effort backend has to archive a batch of quotes")
+			fs.StringSlice("fanout-required", []string{"sqlite"}, "storage backends that must archive quotes; the first serves reads")
+		},
+		New: func(cfg history.Config, log *zap.SugaredLogger) (history.Storage, error) {
+			var targets []Target
+
+			for _, p := range []struct {
+				key    string
+				policy Policy
+			}{
+				{"fanout-required", Required},
+				{"fanout-best-effort", BestEffort},
+			} {
+				// Environment variables arrive as a single comma-separated
+				// value.
+				names := strings.Join(cfg.GetStringSlice(p.key), ",")
+
+				for _, name := range strings.Split(names, ",") {
+					name = strings.TrimSpace(name)
+					switch name {
+					case "":
+						continue
+					case "fanout":
+						return nil, fmt.Errorf("fanout cannot chain itself")
+					}
+
+					s, err := history.New(name, cfg, log)
+					if err != nil {
+						closeTargets(targets)
+						return nil, fmt.Errorf("%s storage: %w", name, err)
+					}
+
+					targets = append(targets,
+						Target{Name: name, Archiver: s, Policy: p.policy})
+				}
+			}
+
+			c, err := New(
+				targets,
+				BestEffortQueue(cfg.GetInt("fanout-best-effort-queue")),
+				BestEffortTimeout(cfg.GetDuration("fanout-best-effort-timeout")),
+			)
+			if err != nil {
+				closeTargets(targets)
+				return nil, err
+			}
+
+			return c.Storage(), nil
+		},
+	})
+}
+
+// closeTargets closes the targets opened before a later one failed.
+func closeTargets(targets []Target) {
+	for _, t := range targets {
+		_ = t.Archiver.Close()
+	}
+}
@@ -1,44 +1,400 @@
 // Package influxdb provides history.Archiver and history.Provider
 // implementations that use the time series database, InfluxDB.
 //
-// Note: This stub is included as an example of how I could add InfluxDB
-// support if required in the future. Abstracting this away from the rest of
-// the code allows me to transparently swap backend implementations (e.g.,
-// SQLite for InfluxDB) as requirements and scaling needs change. For the
-// purposes of this demo, I opted to keep things simple and use SQLite.
+// Each quote is written as a point in the "quotes" measurement, tagged by its
+// stock symbol and, if known, the provider that supplied it, with the quote's
+// price and known daily statistics as fields. Prices are written as floats so
+// Flux can aggregate them, since a float holds any price below a billion
+// closely enough to round back to the same Decimal when it's read.
 package influxdb
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/awoodbeck/faang-stonks/finance"
 	"github.com/awoodbeck/faang-stonks/history"
 	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/api"
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
+)
+
+const (
+	// DefaultBatchSize is the default maximum number of points written to
+	// InfluxDB per request.
+	DefaultBatchSize = 5000
+
+	// DefaultBucket is the default InfluxDB bucket.
+	DefaultBucket = "stonks"
+
+	// DefaultOrg is the default InfluxDB organization.
+	DefaultOrg = "stonks"
+
+	// DefaultTimeout is the default duration the client waits for a response
+	// to each request.
+	DefaultTimeout = 10 * time.Second
+
+	// DefaultURL is the default InfluxDB server URL.
+	DefaultURL = "http://localhost:8086"
+
+	measurement = "quotes"
 )
 
 var (
 	_ history.Archiver = (*Client)(nil)
 	_ history.Provider = (*Client)(nil)
+
+	ErrInvalidBucket = fmt.Errorf("invalid bucket")
+	ErrInvalidOrg    = fmt.Errorf("invalid org")
+	ErrInvalidToken  = fmt.Errorf("invalid token")
 )
 
+// Client implements the history.Archiver and history.Provider interfaces,
+// knowing how to store and retrieve stock quotes, respectively.
 type Client struct {
-	idb influxdb2.Client
+	idb   influxdb2.Client
+	query api.QueryAPI
+	write api.WriteAPIBlocking
+
+	batchSize uint
+	bucket    string
+	org       string
+	symbols   map[string]struct{}
+	timeout   time.Duration
+	token     string
+	url       string
+	useGZip   bool
 }
 
+// Close the client, releasing its resources.
 func (c Client) Close() error {
-	panic("implement me")
+	c.idb.Close()
+
+	return nil
 }
 
+// GetQuotes accepts a stock symbol and the latest quotes for the stock to
+// return.
 func (c Client) GetQuotes(ctx context.Context, symbol string, last int) (
 	[]finance.Quote, error) {
-	panic("implement me")
+	if last < 1 {
+		last = 1
+	}
+
+	return c.GetQuotesRange(ctx, symbol, history.Range{Limit: last})
 }
 
+// GetQuotesBatch accepts a slice of symbols and an integer indicating the last
+// N quotes per symbol to return to the caller.
 func (c Client) GetQuotesBatch(ctx context.Context, symbols []string,
 	last int) (finance.QuoteBatch, error) {
-	panic("implement me")
+	if last < 1 {
+		last = 1
+	}
+
+	return c.GetQuotesBatchRange(ctx, symbols, history.Range{Limit: last})
+}
+
+// GetQuotesRange accepts a stock symbol and a range, and returns the quotes
+// for the stock that fall within the range.
+func (c Client) GetQuotesRange(ctx context.Context, symbol string,
+	r history.Range) ([]finance.Quote, error) {
+	batch, err := c.GetQuotesBatchRange(ctx, []string{symbol}, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return batch[strings.ToLower(symbol)], nil
+}
+
+// GetQuotesBatchRange accepts a slice of symbols and a range, and returns the
+// quotes for each symbol that fall within the range. The client's symbols are
+// used if the symbols slice is empty.
+func (c Client) GetQuotesBatchRange(ctx context.Context, symbols []string,
+	r history.Range) (finance.QuoteBatch, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	if len(symbols) == 0 {
+		for symbol := range c.symbols {
+			symbols = append(symbols, symbol)
+		}
+	}
+	if len(symbols) == 0 {
+		return nil, history.ErrNotFound
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	result, err := c.query.Query(callCtx, c.fluxQuery(symbols, r))
+	if err != nil {
+		return nil, fmt.Errorf("querying quotes: %w", err)
+	}
+	defer func() { _ = result.Close() }()
+
+	batch := make(finance.QuoteBatch)
+
+	for result.Next() {
+		record := result.Record()
+		symbol, _ := record.ValueByKey("symbol").(string)
+		price, ok := record.ValueByKey("price").(float64)
+		if symbol == "" || !ok {
+			return nil, fmt.Errorf("malformed record: %v", record)
+		}
+		provider, _ := record.ValueByKey("provider").(string)
+		source, _ := record.ValueByKey("source").(string)
+		calculationPrice, _ := record.ValueByKey("calculationPrice").(string)
+
+		batch[symbol] = append(batch[symbol], finance.Quote{
+			Price:    finance.NewDecimal(price),
+			Provider: provider,
+			Symbol:   symbol,
+			Time:     record.Time().UTC(),
+
+			Source:           source,
+			CalculationPrice: calculationPrice,
+
+			Open:          decimalField(record.ValueByKey("open")),
+			High:          decimalField(record.ValueByKey("high")),
+			Low:           decimalField(record.ValueByKey("low")),
+			Close:         decimalField(record.ValueByKey("close")),
+			Volume:        intField(record.ValueByKey("volume")),
+			PreviousClose: decimalField(record.ValueByKey("previousClose")),
+			Change:        decimalField(record.ValueByKey("change")),
+			ChangePercent: floatField(record.ValueByKey("changePercent")),
+			MarketCap:     intField(record.ValueByKey("marketCap")),
+			PERatio:       floatField(record.ValueByKey("peRatio")),
+			Week52High:    decimalField(record.ValueByKey("week52High")),
+			Week52Low:     decimalField(record.ValueByKey("week52Low")),
+		})
+	}
+
+	err = result.Err()
+	if err != nil {
+		return nil, fmt.Errorf("query result: %w", err)
+	}
+
+	if len(batch) == 0 {
+		return nil, history.ErrNotFound
+	}
+
+	return batch, nil
 }
 
+// SetQuotes accepts a slice of finance.Quote objects and writes them to
+// InfluxDB, at most batch size points per request.
 func (c Client) SetQuotes(ctx context.Context, quotes []finance.Quote) error {
-	panic("implement me")
+	points := make([]*write.Point, 0, len(quotes))
+	for _, q := range quotes {
+		tags := map[string]string{"symbol": strings.ToLower(q.Symbol)}
+		if q.Provider != "" {
+			tags["provider"] = q.Provider
+		}
+
+		points = append(points, influxdb2.NewPoint(
+			measurement,
+			tags,
+			fields(q),
+			q.Time.UTC(),
+		))
+	}
+
+	for len(points) > 0 {
+		n := len(points)
+		if n > int(c.batchSize) {
+			n = int(c.batchSize)
+		}
+
+		callCtx, cancel := context.WithTimeout(ctx, c.timeout)
+		err := c.write.WritePoint(callCtx, points[:n]...)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("writing quotes: %w", err)
+		}
+
+		points = points[n:]
+	}
+
+	return nil
+}
+
+// fields returns the quote's price and whichever of its source metadata and
+// daily statistics are known as point fields.
+func fields(q finance.Quote) map[string]interface{} {
+	f := map[string]interface{}{"price": q.Price.Float64()}
+
+	for name, v := range map[string]*finance.Decimal{
+		"open":          q.Open,
+		"high":          q.High,
+		"low":           q.Low,
+		"close":         q.Close,
+		"previousClose": q.PreviousClose,
+		"change":        q.Change,
+		"week52High":    q.Week52High,
+		"week52Low":     q.Week52Low,
+	} {
+		if v != nil {
+			f[name] = v.Float64()
+		}
+	}
+
+	for name, v := range map[string]*float64{
+		"changePercent": q.ChangePercent,
+		"peRatio":       q.PERatio,
+	} {
+		if v != nil {
+			f[name] = *v
+		}
+	}
+
+	for name, v := range map[string]*int64{
+		"volume":    q.Volume,
+		"marketCap": q.MarketCap,
+	} {
+		if v != nil {
+			f[name] = *v
+		}
+	}
+
+	for name, v := range map[string]string{
+		"source":           q.Source,
+		"calculationPrice": q.CalculationPrice,
+	} {
+		if v != "" {
+			f[name] = v
+		}
+	}
+
+	return f
+}
+
+// decimalField returns a pointer to the value of a float field as a Decimal,
+// or nil if the point lacks the field.
+func decimalField(v interface{}) *finance.Decimal {
+	f, ok := v.(float64)
+	if !ok {
+		return nil
+	}
+	d := finance.NewDecimal(f)
+
+	return &d
+}
+
+// floatField returns a pointer to the value of a float field, or nil if the
+// point lacks the field.
+func floatField(v interface{}) *float64 {
+	f, ok := v.(float64)
+	if !ok {
+		return nil
+	}
+
+	return &f
+}
+
+// intField returns a pointer to the value of an integer field, or nil if the
+// point lacks the field.
+func intField(v interface{}) *int64 {
+	i, ok := v.(int64)
+	if !ok {
+		return nil
+	}
+
+	return &i
+}
+
+// fluxQuery returns a Flux query that selects the quotes for the given symbols
+// within the range, one table per symbol, limited and ordered per the range.
+func (c Client) fluxQuery(symbols []string, r history.Range) string {
+	set := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		set = append(set, fmt.Sprintf("%q", strings.ToLower(symbol)))
+	}
+
+	start := "0"
+	if !r.From.IsZero() {
+		start = r.From.UTC().Format(time.RFC3339Nano)
+	}
+
+	stop := "now()"
+	if !r.To.IsZero() {
+		stop = r.To.UTC().Format(time.RFC3339Nano)
+	}
+
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "from(bucket: %q)\n", c.bucket)
+	_, _ = fmt.Fprintf(&b, "  |> range(start: %s, stop: %s)\n", start, stop)
+	_, _ = fmt.Fprintf(&b, "  |> filter(fn: (r) => r._measurement == %q)\n",
+		measurement)
+	_, _ = fmt.Fprintf(&b,
+		"  |> filter(fn: (r) => contains(value: r.symbol, set: [%s]))\n",
+		strings.Join(set, ", "))
+	b.WriteString(`  |> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")` + "\n")
+	b.WriteString(`  |> group(columns: ["symbol"])` + "\n")
+	_, _ = fmt.Fprintf(&b, "  |> sort(columns: [\"_time\"], desc: %t)",
+		r.Order == history.Descending)
+	if r.Limit > 0 {
+		_, _ = fmt.Fprintf(&b, "\n  |> limit(n: %d)", r.Limit)
+	}
+
+	return b.String()
+}
+
+// New returns a pointer to a new Client object after applying optional
+// settings.
+//
+// Defaults:
+//     BatchSize = 5000
+//     Bucket    = "stonks"
+//     Org       = "stonks"
+//     Symbols   = default symbols from finance package
+//     Timeout   = 10 * time.Second
+//     URL       = "http://localhost:8086"
+func New(token string, options ...Option) (*Client, error) {
+	if token == "" {
+		return nil, ErrInvalidToken
+	}
+
+	c := &Client{
+		batchSize: DefaultBatchSize,
+		bucket:    DefaultBucket,
+		org:       DefaultOrg,
+		symbols:   make(map[string]struct{}),
+		timeout:   DefaultTimeout,
+		token:     token,
+		url:       DefaultURL,
+	}
+
+	for _, symbol := range finance.DefaultSymbols {
+		c.symbols[symbol] = struct{}{}
+	}
+
+	for _, option := range options {
+		if option != nil {
+			option(c)
+		}
+	}
+
+	switch {
+	case c.bucket == "":
+		return nil, ErrInvalidBucket
+	case c.org == "":
+		return nil, ErrInvalidOrg
+	}
+
+	// The HTTP client's timeout is in whole seconds, where zero means none,
+	// so round the timeout up rather than truncating a sub-second timeout.
+	c.idb = influxdb2.NewClientWithOptions(c.url, c.token,
+		influxdb2.DefaultOptions().
+			SetBatchSize(c.batchSize).
+			SetHTTPRequestTimeout(uint((c.timeout+time.Second-1)/time.Second)).
+			SetUseGZip(c.useGZip),
+	)
+	c.query = c.idb.QueryAPI(c.org)
+	c.write = c.idb.WriteAPIBlocking(c.org, c.bucket)
+
+	return c, nil
 }
@@ -0,0 +1,219 @@
+package influxdb
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/awoodbeck/faang-stonks/history"
+)
+
+// dec parses a Decimal constant, such as a price.
+var dec = finance.MustParseDecimal
+
+// influxStandIn mimics the InfluxDB v2 write and query HTTP API endpoints. It
+// records the line protocol written to it and the Flux queries it receives,
+// and answers every query with a canned annotated CSV response.
+type influxStandIn struct {
+	mu      sync.Mutex
+	writes  []string
+	queries []string
+	csv     string
+}
+
+func (s *influxStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Token stonks!" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"code":"unauthorized","message":"unauthorized access"}`))
+		return
+	}
+
+	switch r.URL.Path {
+	case "/api/v2/write":
+		if r.URL.Query().Get("org") != "acme" ||
+			r.URL.Query().Get("bucket") != "quotes" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"code":"not found","message":"bucket not found"}`))
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		s.writes = append(s.writes, string(b))
+		w.WriteHeader(http.StatusNoContent)
+	case "/api/v2/query":
+		var q struct {
+			Query string `json:"query"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&q)
+		s.queries = append(s.queries, q.Query)
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		_, _ = w.Write([]byte(s.csv))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestClient(t *testing.T, csv string) (*Client, *influxStandIn) {
+	s := &influxStandIn{csv: csv}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+
+	c, err := New("stonks!", URL(srv.URL), Org("acme"), Bucket("quotes"),
+		BatchSize(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	return c, s
+}
+
+func TestNewClient(t *testing.T) {
+	t.Parallel()
+
+	_, err := New("")
+	if err != ErrInvalidToken {
+		t.Errorf("expected: ErrInvalidToken; actual: %v", err)
+	}
+
+	c, err := New("stonks!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = c.Close() }()
+
+	if c.url != DefaultURL || c.org != DefaultOrg || c.bucket != DefaultBucket {
+		t.Errorf("unexpected defaults: %q, %q, %q", c.url, c.org, c.bucket)
+	}
+	if c.batchSize != DefaultBatchSize {
+		t.Errorf("expected batch size: %d; actual: %d", DefaultBatchSize,
+			c.batchSize)
+	}
+
+	for _, tc := range []struct {
+		timeout  time.Duration
+		expected uint
+	}{
+		{timeout: 500 * time.Millisecond, expected: 1},
+		{timeout: time.Second, expected: 1},
+		{timeout: 1500 * time.Millisecond, expected: 2},
+	} {
+		c, err := New("stonks!", Timeout(tc.timeout))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := c.idb.Options().HTTPRequestTimeout(); actual != tc.expected {
+			t.Errorf("%s: expected HTTP timeout: %ds; actual: %ds", tc.timeout,
+				tc.expected, actual)
+		}
+		_ = c.Close()
+	}
+}
+
+func TestSetQuotes(t *testing.T) {
+	t.Parallel()
+
+	c, s := newTestClient(t, "")
+
+	now := time.Unix(1620415867, 272000000)
+	open, volume := dec("129.8"), int64(88071229)
+	err := c.SetQuotes(context.Background(), []finance.Quote{
+		{Price: dec("130.4"), Symbol: "AAPL", Time: now, Open: &open,
+			Volume: &volume},
+		{Price: dec("3296.16"), Symbol: "amzn", Time: now},
+		{Price: dec("320.125"), Provider: "iexcloud", Symbol: "fb", Time: now},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"quotes,symbol=aapl open=129.8,price=130.4,volume=88071229i 1620415867272000000\n" +
+			"quotes,symbol=amzn price=3296.16 1620415867272000000\n",
+		"quotes,provider=iexcloud,symbol=fb price=320.125 1620415867272000000\n",
+	}
+	if !reflect.DeepEqual(s.writes, expected) {
+		t.Error("actual writes not equal to expected")
+		t.Logf("expected: %q", expected)
+		t.Logf("actual:   %q", s.writes)
+	}
+}
+
+func TestGetQuotesBatchRange(t *testing.T) {
+	t.Parallel()
+
+	c, s := newTestClient(t, `#datatype,string,long,dateTime:RFC3339,dateTime:RFC3339,dateTime:RFC3339,string,string,string,double
+#group,false,false,true,true,false,true,false,true,false
+#default,_result,,,,,,,,
+,result,table,_start,_stop,_time,_measurement,provider,symbol,price
+,,0,2021-05-07T00:00:00Z,2021-05-08T00:00:00Z,2021-05-07T19:31:07.272Z,quotes,simulated,fb,320.125
+,,0,2021-05-07T00:00:00Z,2021-05-08T00:00:00Z,2021-05-07T19:30:07Z,quotes,,fb,320.1
+,,1,2021-05-07T00:00:00Z,2021-05-08T00:00:00Z,2021-05-07T19:30:21Z,quotes,,goog,2402.14
+
+`)
+
+	from := time.Date(2021, 5, 7, 0, 0, 0, 0, time.UTC)
+	batch, err := c.GetQuotesBatchRange(context.Background(),
+		[]string{"FB", "goog"}, history.Range{
+			From:  from,
+			To:    from.Add(24 * time.Hour),
+			Limit: 2,
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := finance.QuoteBatch{
+		"fb": {
+			{Price: dec("320.125"), Provider: "simulated", Symbol: "fb",
+				Time: time.Date(2021, 5, 7, 19, 31, 7, 272000000, time.UTC)},
+			{Price: dec("320.1"), Symbol: "fb",
+				Time: time.Date(2021, 5, 7, 19, 30, 7, 0, time.UTC)},
+		},
+		"goog": {
+			{Price: dec("2402.14"), Symbol: "goog",
+				Time: time.Date(2021, 5, 7, 19, 30, 21, 0, time.UTC)},
+		},
+	}
+	if !reflect.DeepEqual(batch, expected) {
+		t.Error("actual batch not equal to expected")
+		t.Logf("expected: %#v", expected)
+		t.Logf("actual:   %#v", batch)
+	}
+
+	if len(s.queries) != 1 {
+		t.Fatalf("expected 1 query; actual: %d", len(s.queries))
+	}
+	for _, fragment := range []string{
+		`from(bucket: "quotes")`,
+		`range(start: 2021-05-07T00:00:00Z, stop: 2021-05-08T00:00:00Z)`,
+		`contains(value: r.symbol, set: ["fb", "goog"])`,
+		`sort(columns: ["_time"], desc: true)`,
+		`limit(n: 2)`,
+	} {
+		if !strings.Contains(s.queries[0], fragment) {
+			t.Errorf("query missing %q:\n%s", fragment, s.queries[0])
+		}
+	}
+}
+
+func TestGetQuotesNotFound(t *testing.T) {
+	t.Parallel()
+
+	c, _ := newTestClient(t, "")
+
+	_, err := c.GetQuotes(context.Background(), "fb", 1)
+	if err != history.ErrNotFound {
+		t.Errorf("expected: ErrNotFound; actual: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package influxdb
+
+import (
+	"strings"
+	"time"
+)
+
+type Option func(*Client)
+
+// BatchSize sets the maximum number of points written to InfluxDB per
+// request.
+func BatchSize(size uint) Option {
+	return func(c *Client) {
+		if size > 0 {
+			c.batchSize = size
+		}
+	}
+}
+
+// Bucket specifies the InfluxDB bucket to store quotes in.
+func Bucket(bucket string) Option {
+	return func(c *Client) {
+		if bucket != "" {
+			c.bucket = bucket
+		}
+	}
+}
+
+// Org specifies the InfluxDB organization that owns the bucket.
+func Org(org string) Option {
+	return func(c *Client) {
+		if org != "" {
+			c.org = org
+		}
+	}
+}
+
+// Symbols configures the Archiver to track specific stock symbols.
+func Symbols(symbols []string) Option {
+	return func(c *Client) {
+		c.symbols = make(map[string]struct{})
+
+		for _, symbol := range symbols {
+			c.symbols[strings.ToLower(symbol)] = struct{}{}
+		}
+	}
+}
+
+// Timeout accepts a duration that the client should wait for a response to
+// each request.
+func Timeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		if timeout > 0 {
+			c.timeout = timeout
+		}
+	}
+}
+
+// URL specifies the InfluxDB server URL.
+func URL(url string) Option {
+	return func(c *Client) {
+		if url != "" {
+			c.url = url
+		}
+	}
+}
+
+// UseGZip enables gzip compression of write requests.
+func UseGZip(enabled bool) Option {
+	return func(c *Client) {
+		c.useGZip = enabled
+	}
+}
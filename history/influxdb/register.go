@@ -0,0 +1,33 @@
+package influxdb
+
+import (
+	"github.com/awoodbeck/faang-stonks/history"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+func init() {
+	history.Register("influxdb", history.Backend{
+		Flags: func(fs *pflag.FlagSet) {
+			fs.Uint("influxdb-batch-size", DefaultBatchSize, "max points written to InfluxDB per request")
+			fs.String("influxdb-bucket", DefaultBucket, "InfluxDB bucket")
+			fs.Bool("influxdb-gzip", false, "compress InfluxDB write requests")
+			fs.String("influxdb-org", DefaultOrg, "InfluxDB organization")
+			fs.Duration("influxdb-timeout", DefaultTimeout, "InfluxDB request timeout")
+			fs.String("influxdb-token", "", "InfluxDB API token")
+			fs.String("influxdb-url", DefaultURL, "InfluxDB server URL")
+		},
+		New: func(cfg history.Config, _ *zap.SugaredLogger) (history.Storage, error) {
+			return New(
+				cfg.GetString("influxdb-token"),
+				BatchSize(cfg.GetUint("influxdb-batch-size")),
+				Bucket(cfg.GetString("influxdb-bucket")),
+				Org(cfg.GetString("influxdb-org")),
+				Symbols(cfg.GetStringSlice("symbols")),
+				Timeout(cfg.GetDuration("influxdb-timeout")),
+				URL(cfg.GetString("influxdb-url")),
+				UseGZip(cfg.GetBool("influxdb-gzip")),
+			)
+		},
+	})
+}
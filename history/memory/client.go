@@ -5,8 +5,10 @@
 // implementation may fit the business case when we only care about stock
 // prices while the service runs. The downside is RAM is more expensive than
 // disk, so we have to be mindful of the growing memory consumption of this
-// approach. That said, we could use something like a fixed queue to limit
-// the number of quotes per symbol we track.
+// approach. To that end, each symbol's quotes live in a ring buffer that
+// holds a limited number of quotes, optionally discarding quotes older than
+// a maximum age, too. Quotes don't survive a restart unless the client
+// periodically snapshots them to a file, which it restores on startup.
 //
 // Side note: I punt on time zone handling in this example, storing timestamps
 // as-is. That isn't suitable in production, of course. You can see how I
@@ -16,31 +18,153 @@ package memory
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/awoodbeck/faang-stonks/finance"
 	"github.com/awoodbeck/faang-stonks/history"
+	"github.com/awoodbeck/faang-stonks/metrics"
 	"go.uber.org/multierr"
+	"go.uber.org/zap"
 )
 
+// DefaultCapacity is the default number of quotes the client stores for each
+// symbol.
+const DefaultCapacity = 7 * 24 * 60
+
 var (
-	_ history.Archiver = (*Client)(nil)
-	_ history.Provider = (*Client)(nil)
+	_ history.Archiver       = (*Client)(nil)
+	_ history.CandleProvider = (*Client)(nil)
+	_ history.Provider       = (*Client)(nil)
+	_ history.SymbolRegistry = (*Client)(nil)
 )
 
 // Client implements the history.Archiver and history.Provider interfaces,
-// knowing how to store and retrieve stock quotes, respectively.
+// knowing how to store and retrieve stock quotes, respectively. It also
+// implements the history.SymbolRegistry interface, tracking the symbols
+// whose quotes it stores.
 type Client struct {
-	mu     sync.RWMutex
-	quotes map[string][]finance.Quote
+	mu      sync.RWMutex
+	quotes  map[string]*ring
+	tracked map[string]struct{}
+
+	capacity int
+	log      *zap.SugaredLogger
+	maxAge   time.Duration
+	now      func() time.Time
+
+	snapshotFile     string
+	snapshotInterval time.Duration
+	closeOnce        sync.Once
+	closed           chan struct{}
+	snapshotDone     chan struct{}
 }
 
-// Close is essentially a no-op for this client.
-func (c *Client) Close() error {
+// AddSymbol tracks the stock symbol, if it isn't already tracked, so the
+// client stores its quotes. A symbol tracked again keeps the quotes it had.
+func (c *Client) AddSymbol(_ context.Context, symbol string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.track(strings.ToLower(symbol))
+
 	return nil
 }
 
+// Close stops periodic snapshots and, if the client has a snapshot file,
+// writes a final snapshot to it. Otherwise, it's a no-op.
+func (c *Client) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		if c.snapshotFile == "" {
+			return
+		}
+
+		close(c.closed)
+		if c.snapshotDone != nil {
+			<-c.snapshotDone
+		}
+		err = c.writeSnapshotFile()
+	})
+
+	return err
+}
+
+// GetCandles accepts a stock symbol, an interval, and a range. It returns a
+// slice of history.Candle objects aggregated from the stock's quotes that fall
+// within the range.
+func (c *Client) GetCandles(_ context.Context, symbol string,
+	interval time.Duration, r history.Range) ([]history.Candle, error) {
+	if err := history.ValidateInterval(interval); err != nil {
+		return nil, err
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	buf, ok := c.quotes[strings.ToLower(symbol)]
+	if !ok {
+		c.mu.RUnlock()
+		return nil, history.ErrNotFound
+	}
+	// Walk the quotes oldest first, so each candle's open precedes its close.
+	quotes := quotesInRange(buf, c.cutoff(), history.Range{
+		From:  r.From,
+		To:    r.To,
+		Order: history.Ascending,
+	})
+	c.mu.RUnlock()
+
+	var (
+		candles []history.Candle
+		seconds = int64(interval / time.Second)
+	)
+	for _, q := range quotes {
+		bucket := time.Unix(q.Time.Unix()/seconds*seconds, 0).UTC()
+
+		if n := len(candles); n > 0 && candles[n-1].Time.Equal(bucket) {
+			candle := &candles[n-1]
+			if q.Price > candle.High {
+				candle.High = q.Price
+			}
+			if q.Price < candle.Low {
+				candle.Low = q.Price
+			}
+			candle.Close = q.Price
+			candle.Count++
+			continue
+		}
+
+		candles = append(candles, history.Candle{
+			Symbol: strings.ToLower(symbol),
+			Time:   bucket,
+			Open:   q.Price,
+			High:   q.Price,
+			Low:    q.Price,
+			Close:  q.Price,
+			Count:  1,
+		})
+	}
+
+	if len(candles) == 0 {
+		return nil, history.ErrNotFound
+	}
+
+	if r.Order == history.Descending {
+		for i, j := 0, len(candles)-1; i < j; i, j = i+1, j-1 {
+			candles[i], candles[j] = candles[j], candles[i]
+		}
+	}
+	if r.Limit > 0 && len(candles) > r.Limit {
+		candles = candles[:r.Limit]
+	}
+
+	return candles, nil
+}
+
 // GetQuotes accepts a stock symbol and the last N quotes for the stock. It
 // returns a slice of finance.Quote objects for the stock.
 func (c *Client) GetQuotes(_ context.Context, symbol string, last int) (
@@ -48,7 +172,7 @@ func (c *Client) GetQuotes(_ context.Context, symbol string, last int) (
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	quotes, ok := c.quotes[strings.ToLower(symbol)]
+	buf, ok := c.quotes[strings.ToLower(symbol)]
 	if !ok {
 		return nil, history.ErrNotFound
 	}
@@ -56,12 +180,12 @@ func (c *Client) GetQuotes(_ context.Context, symbol string, last int) (
 	if last < 1 {
 		last = 1
 	}
-	if len(quotes) < last {
-		last = len(quotes)
+	if n := buf.len(c.cutoff()); n < last {
+		last = n
 	}
 
 	out := make([]finance.Quote, last)
-	copy(out, quotes)
+	buf.copyTo(out)
 
 	return out, nil
 }
@@ -75,68 +199,262 @@ func (c *Client) GetQuotesBatch(_ context.Context, symbols []string,
 	defer c.mu.RUnlock()
 
 	if len(symbols) == 0 {
-		symbols = finance.DefaultSymbols
+		symbols = c.symbols()
 	}
 
 	batch := make(finance.QuoteBatch)
+	cutoff := c.cutoff()
 	for _, symbol := range symbols {
-		quotes, ok := c.quotes[strings.ToLower(symbol)]
+		buf, ok := c.quotes[strings.ToLower(symbol)]
 		if !ok {
 			return nil, history.ErrNotFound
 		}
-		if len(quotes) == 0 {
+		n := buf.len(cutoff)
+		if n == 0 {
 			continue
 		}
 
 		if last < 1 {
 			last = 1
 		}
-		if len(quotes) < last {
-			last = len(quotes)
+		if n < last {
+			last = n
 		}
 
 		batch[symbol] = make([]finance.Quote, last)
-		copy(batch[symbol], quotes)
+		buf.copyTo(batch[symbol])
 	}
 
 	return batch, nil
 }
 
+// GetQuotesRange accepts a stock symbol and a range. It returns a slice of
+// finance.Quote objects for the stock that fall within the range.
+func (c *Client) GetQuotesRange(_ context.Context, symbol string,
+	r history.Range) ([]finance.Quote, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	buf, ok := c.quotes[strings.ToLower(symbol)]
+	if !ok {
+		return nil, history.ErrNotFound
+	}
+
+	out := quotesInRange(buf, c.cutoff(), r)
+	if len(out) == 0 {
+		return nil, history.ErrNotFound
+	}
+
+	return out, nil
+}
+
+// GetQuotesBatchRange accepts a slice of stock symbols and a range. It returns
+// a map where each key is a stock symbol and the value is the stock's quotes
+// that fall within the range.
+func (c *Client) GetQuotesBatchRange(_ context.Context, symbols []string,
+	r history.Range) (finance.QuoteBatch, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if len(symbols) == 0 {
+		symbols = c.symbols()
+	}
+
+	batch := make(finance.QuoteBatch)
+	cutoff := c.cutoff()
+	for _, symbol := range symbols {
+		buf, ok := c.quotes[strings.ToLower(symbol)]
+		if !ok {
+			return nil, history.ErrNotFound
+		}
+
+		if out := quotesInRange(buf, cutoff, r); len(out) > 0 {
+			batch[symbol] = out
+		}
+	}
+
+	if len(batch) == 0 {
+		return nil, history.ErrNotFound
+	}
+
+	return batch, nil
+}
+
+// cutoff returns the time before which quotes have expired, or the zero time
+// if quotes don't expire.
+func (c *Client) cutoff() time.Time {
+	if c.maxAge <= 0 {
+		return time.Time{}
+	}
+
+	return c.now().Add(-c.maxAge)
+}
+
+// quotesInRange returns a copy of the buffered quotes newer than the cutoff
+// that fall within the range.
+func quotesInRange(buf *ring, cutoff time.Time,
+	r history.Range) []finance.Quote {
+	var out []finance.Quote
+
+	n := buf.len(cutoff)
+	for i := 0; i < n; i++ {
+		q := buf.at(i)
+		if r.Order == history.Ascending {
+			q = buf.at(n - 1 - i)
+		}
+		if !r.Contains(q.Time) {
+			continue
+		}
+
+		out = append(out, q)
+		if r.Limit > 0 && len(out) == r.Limit {
+			break
+		}
+	}
+
+	return out
+}
+
+// RemoveSymbol stops tracking the stock symbol, so the client stores no new
+// quotes for it. Like the sqlite.Client, it keeps the quotes it has, which
+// remain readable until they age out or the symbol is tracked again.
+func (c *Client) RemoveSymbol(_ context.Context, symbol string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	symbol = strings.ToLower(symbol)
+	if _, ok := c.tracked[symbol]; !ok {
+		return history.ErrNotFound
+	}
+	delete(c.tracked, symbol)
+
+	return nil
+}
+
 // SetQuotes accepts a slice of finance.Quote objects and archives them to
-// the appropriate in-memory slice.
+// the appropriate in-memory ring buffer, discarding the oldest quotes once the
+// buffer is full and any quotes older than the maximum age. It skips quotes
+// whose symbol and timestamp are already stored.
 func (c *Client) SetQuotes(_ context.Context, quotes []finance.Quote) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	var err error
+	cutoff := c.cutoff()
 	for _, quote := range quotes {
 		symbol := strings.ToLower(quote.Symbol)
-		quotes, ok := c.quotes[symbol]
-		if !ok {
-			multierr.AppendInto(&err, fmt.Errorf("symbol %q not found", quote.Symbol))
+		if _, ok := c.tracked[symbol]; !ok {
+			multierr.AppendInto(&err, fmt.Errorf("symbol %q not found: %w",
+				quote.Symbol, history.ErrRejected))
+			continue
+		}
+		buf := c.quotes[symbol]
+		if stored(buf, quote.Time) {
+			metrics.DuplicateQuotes.WithLabelValues("memory").Inc()
 			continue
 		}
 
-		c.quotes[symbol] = append([]finance.Quote{quote}, quotes...)
+		buf.push(quote, c.capacity)
+		buf.trim(cutoff)
 	}
 
 	return err
 }
 
+// stored returns true if the buffered quotes include one with the given
+// timestamp. New quotes are usually the newest, so it stops at the first
+// older quote rather than walking the whole buffer.
+func stored(buf *ring, t time.Time) bool {
+	for i := 0; i < buf.n; i++ {
+		switch q := buf.at(i); {
+		case q.Time.Equal(t):
+			return true
+		case q.Time.Before(t):
+			return false
+		}
+	}
+
+	return false
+}
+
+// Symbols returns the tracked stock symbols in alphabetical order.
+func (c *Client) Symbols(_ context.Context) ([]string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.symbols(), nil
+}
+
+// symbols returns the tracked stock symbols in alphabetical order. The caller
+// must hold the lock.
+func (c *Client) symbols() []string {
+	symbols := make([]string, 0, len(c.tracked))
+	for symbol := range c.tracked {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	return symbols
+}
+
+// track tracks the lowercase symbol, giving it a ring buffer unless it kept
+// one from when it was last tracked. The caller must hold the write lock.
+func (c *Client) track(symbol string) {
+	if _, ok := c.quotes[symbol]; !ok {
+		c.quotes[symbol] = new(ring)
+	}
+	c.tracked[symbol] = struct{}{}
+}
+
 // New returns a pointer to a new Client object after applying optional settings.
 //
+// If the client has a snapshot file, New restores the symbols and quotes in
+// it, if any, which take precedence over the configured symbols.
+//
 // Defaults:
-//     Symbols = finance package default symbols
-func New(options ...Option) *Client {
-	c := &Client{quotes: make(map[string][]finance.Quote)}
+//     Capacity         = 10080 quotes per symbol (a week of quotes polled each minute)
+//     Logger           = no-op logger
+//     MaxAge           = 0 (no max age)
+//     SnapshotFile     = "" (no snapshots)
+//     SnapshotInterval = 1 minute
+//     Symbols          = finance package default symbols
+func New(options ...Option) (*Client, error) {
+	c := &Client{
+		quotes:           make(map[string]*ring),
+		tracked:          make(map[string]struct{}),
+		capacity:         DefaultCapacity,
+		log:              zap.NewNop().Sugar(),
+		now:              time.Now,
+		snapshotInterval: DefaultSnapshotInterval,
+	}
 
 	for _, symbol := range finance.DefaultSymbols {
-		c.quotes[strings.ToLower(symbol)] = []finance.Quote{}
+		c.track(strings.ToLower(symbol))
 	}
 
 	for _, option := range options {
 		option(c)
 	}
 
-	return c
+	if c.snapshotFile != "" {
+		if err := c.restoreFile(); err != nil {
+			return nil, err
+		}
+
+		c.closed = make(chan struct{})
+		if c.snapshotInterval > 0 {
+			c.snapshotDone = make(chan struct{})
+			go c.snapshotEvery(c.snapshotInterval)
+		}
+	}
+
+	return c, nil
 }
@@ -1,18 +1,29 @@
 package memory
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/awoodbeck/faang-stonks/history"
 )
 
+// dec parses a Decimal constant, such as a price.
+var dec = finance.MustParseDecimal
+
 func TestNewClient(t *testing.T) {
 	t.Parallel()
 
-	c := New()
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if len(finance.DefaultSymbols) != len(c.quotes) {
 		t.Errorf("the quotes map length mismatches the default symbols slice length")
@@ -29,7 +40,10 @@ func TestNewClientOptions(t *testing.T) {
 	t.Parallel()
 
 	symbols := []string{"foo", "bar"}
-	c := New(Symbols(symbols))
+	c, err := New(Symbols(symbols))
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if len(symbols) != len(c.quotes) {
 		t.Errorf("the quotes map length mismatches the optional symbols slice length")
@@ -45,6 +59,7 @@ func TestNewClientOptions(t *testing.T) {
 func TestGetQuotes(t *testing.T) {
 	t.Parallel()
 
+	now := time.Now()
 	testCases := []struct {
 		quotes   []finance.Quote
 		symbol   string
@@ -53,21 +68,24 @@ func TestGetQuotes(t *testing.T) {
 	}{
 		{ // add multiple quotes but return the last one added
 			quotes: []finance.Quote{
-				{Price: 123.45, Symbol: "fb"},
-				{Price: 123.42, Symbol: "fb"},
+				{Price: dec("123.45"), Symbol: "fb", Time: now},
+				{Price: dec("123.42"), Symbol: "fb", Time: now.Add(time.Second)},
 			},
 			symbol: "fb",
 			last:   0,
 			expected: []finance.Quote{
-				{Price: 123.42, Symbol: "fb"},
+				{Price: dec("123.42"), Symbol: "fb", Time: now.Add(time.Second)},
 			},
 		},
 	}
 
-	c := New()
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	for i, tc := range testCases {
-		err := c.SetQuotes(context.Background(), tc.quotes)
+		err = c.SetQuotes(context.Background(), tc.quotes)
 		if err != nil {
 			t.Errorf("%d: set actual: %v", i, err)
 			continue
@@ -90,6 +108,7 @@ func TestGetQuotes(t *testing.T) {
 func TestGetQuotesBatch(t *testing.T) {
 	t.Parallel()
 
+	now := time.Now()
 	testCases := []struct {
 		quotes   []finance.Quote
 		symbols  []string
@@ -98,27 +117,30 @@ func TestGetQuotesBatch(t *testing.T) {
 	}{
 		{
 			quotes: []finance.Quote{
-				{Price: 123.45, Symbol: "fb"},
-				{Price: 123.42, Symbol: "fb"},
-				{Price: 234.56, Symbol: "goog"},
+				{Price: dec("123.45"), Symbol: "fb", Time: now},
+				{Price: dec("123.42"), Symbol: "fb", Time: now.Add(time.Second)},
+				{Price: dec("234.56"), Symbol: "goog"},
 			},
 			symbols: []string{"fb", "goog"},
 			last:    0,
 			expected: finance.QuoteBatch{
 				"fb": {
-					{Price: 123.42, Symbol: "fb"},
+					{Price: dec("123.42"), Symbol: "fb", Time: now.Add(time.Second)},
 				},
 				"goog": {
-					{Price: 234.56, Symbol: "goog"},
+					{Price: dec("234.56"), Symbol: "goog"},
 				},
 			},
 		},
 	}
 
-	c := New()
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	for i, tc := range testCases {
-		err := c.SetQuotes(context.Background(), tc.quotes)
+		err = c.SetQuotes(context.Background(), tc.quotes)
 		if err != nil {
 			t.Errorf("%d: set actual: %v", i, err)
 			continue
@@ -137,3 +159,443 @@ func TestGetQuotesBatch(t *testing.T) {
 		}
 	}
 }
+
+func TestGetQuotesBatchTracked(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now()
+	c, err := New(Symbols([]string{"tsla", "fb"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	quotes := []finance.Quote{
+		{Price: dec("123.45"), Symbol: "fb", Time: now},
+		{Price: dec("234.56"), Symbol: "tsla", Time: now},
+	}
+	if err = c.SetQuotes(ctx, quotes); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without symbols, the batches cover the tracked symbols, not the defaults.
+	expected := finance.QuoteBatch{"fb": quotes[:1], "tsla": quotes[1:]}
+	actual, err := c.GetQuotesBatch(ctx, nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("actual batch: %#v; expected: %#v", actual, expected)
+	}
+
+	actual, err = c.GetQuotesBatchRange(ctx, nil,
+		history.Range{From: now.Add(-time.Minute), To: now.Add(time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("actual batch range: %#v; expected: %#v", actual, expected)
+	}
+}
+
+func TestGetQuotesRange(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	quotes := []finance.Quote{
+		{Price: dec("123.45"), Symbol: "fb", Time: now},
+		{Price: dec("123.42"), Symbol: "fb", Time: now.Add(time.Minute)},
+		{Price: dec("123.40"), Symbol: "fb", Time: now.Add(2 * time.Minute)},
+		{Price: dec("123.38"), Symbol: "fb", Time: now.Add(3 * time.Minute)},
+	}
+
+	testCases := []struct {
+		r        history.Range
+		expected []finance.Quote
+		err      error
+	}{
+		{ // bounded window, newest first
+			r: history.Range{
+				From: now.Add(time.Minute),
+				To:   now.Add(3 * time.Minute),
+			},
+			expected: []finance.Quote{quotes[2], quotes[1]},
+		},
+		{ // open-ended window, oldest first, limited
+			r: history.Range{
+				From:  now.Add(time.Minute),
+				Limit: 2,
+				Order: history.Ascending,
+			},
+			expected: []finance.Quote{quotes[1], quotes[2]},
+		},
+		{ // nothing in range
+			r:   history.Range{To: now},
+			err: history.ErrNotFound,
+		},
+		{ // inverted range
+			r:   history.Range{From: now, To: now.Add(-time.Minute)},
+			err: history.ErrInvalidRange,
+		},
+	}
+
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.SetQuotes(context.Background(), quotes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tc := range testCases {
+		actual, err := c.GetQuotesRange(context.Background(), "fb", tc.r)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%d: actual error: %v; expected: %v", i, err, tc.err)
+			continue
+		}
+
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("%d: actual quotes not equal to expected", i)
+			t.Logf("expected: %#v", tc.expected)
+			t.Logf("actual:   %#v", actual)
+		}
+	}
+
+	batch, err := c.GetQuotesBatchRange(context.Background(),
+		[]string{"fb", "goog"}, history.Range{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := finance.QuoteBatch{"fb": {quotes[3]}}
+	if !reflect.DeepEqual(batch, expected) {
+		t.Error("actual batch not equal to expected")
+		t.Logf("expected: %#v", expected)
+		t.Logf("actual:   %#v", batch)
+	}
+}
+
+func TestGetCandles(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2021, 5, 7, 14, 0, 0, 0, time.UTC)
+	quotes := []finance.Quote{
+		{Price: dec("100"), Symbol: "fb", Time: start.Add(10 * time.Second)},
+		{Price: dec("105"), Symbol: "fb", Time: start.Add(time.Minute)},
+		{Price: dec("95"), Symbol: "fb", Time: start.Add(3 * time.Minute)},
+		{Price: dec("102"), Symbol: "fb",
+			Time: start.Add(5*time.Minute - time.Millisecond)},
+		{Price: dec("110"), Symbol: "fb", Time: start.Add(5 * time.Minute)},
+	}
+
+	testCases := []struct {
+		interval time.Duration
+		r        history.Range
+		expected []history.Candle
+		err      error
+	}{
+		{
+			interval: 5 * time.Minute,
+			expected: []history.Candle{
+				{Symbol: "fb", Time: start.Add(5 * time.Minute), Open: dec("110"),
+					High: dec("110"), Low: dec("110"), Close: dec("110"), Count: 1},
+				{Symbol: "fb", Time: start, Open: dec("100"),
+					High: dec("105"), Low: dec("95"), Close: dec("102"),
+					Count: 4},
+			},
+		},
+		{
+			interval: 2 * time.Minute,
+			r: history.Range{
+				From:  start.Add(time.Minute),
+				Limit: 2,
+				Order: history.Ascending,
+			},
+			expected: []history.Candle{
+				{Symbol: "fb", Time: start, Open: dec("105"),
+					High: dec("105"), Low: dec("105"), Close: dec("105"),
+					Count: 1},
+				{Symbol: "fb", Time: start.Add(2 * time.Minute), Open: dec("95"),
+					High: dec("95"), Low: dec("95"), Close: dec("95"), Count: 1},
+			},
+		},
+		{
+			interval: time.Millisecond,
+			err:      history.ErrInvalidInterval,
+		},
+		{
+			interval: time.Minute,
+			r:        history.Range{To: start},
+			err:      history.ErrNotFound,
+		},
+	}
+
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.SetQuotes(context.Background(), quotes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tc := range testCases {
+		actual, err := c.GetCandles(context.Background(), "fb", tc.interval, tc.r)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%d: actual error: %v; expected: %v", i, err, tc.err)
+			continue
+		}
+
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("%d: actual candles not equal to expected", i)
+			t.Logf("expected: %#v", tc.expected)
+			t.Logf("actual:   %#v", actual)
+		}
+	}
+}
+
+func TestSetQuotesDuplicates(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now()
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, quotes := range [][]finance.Quote{
+		{
+			{Price: dec("123.45"), Symbol: "fb", Time: now},
+			{Price: dec("234.56"), Symbol: "goog", Time: now},
+		},
+		{
+			{Price: dec("123.45"), Symbol: "FB", Time: now},
+			{Price: dec("234.56"), Symbol: "goog", Time: now},
+			{Price: dec("123.42"), Symbol: "fb", Time: now.Add(time.Second)},
+			{Price: dec("123.40"), Symbol: "fb", Time: now.Add(-time.Second)},
+		},
+	} {
+		if err := c.SetQuotes(ctx, quotes); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	batch, err := c.GetQuotesBatch(ctx, []string{"fb", "goog"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := len(batch["fb"]); actual != 3 {
+		t.Errorf("actual fb quotes: %d; expected: 3", actual)
+	}
+	if actual := len(batch["goog"]); actual != 1 {
+		t.Errorf("actual goog quotes: %d; expected: 1", actual)
+	}
+}
+
+func TestRetention(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now()
+	c, err := New(Capacity(3), MaxAge(time.Hour), Symbols([]string{"fb"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.now = func() time.Time { return now }
+
+	for _, minutes := range []int{-90, -50, -40, -30, -20} {
+		err = c.SetQuotes(ctx, []finance.Quote{{
+			Price:  finance.Decimal(minutes),
+			Symbol: "fb",
+			Time:   now.Add(time.Duration(minutes) * time.Minute),
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The capacity keeps the newest 3 quotes.
+	quotes, err := c.GetQuotes(ctx, "fb", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 3 || quotes[0].Price != -20 || quotes[2].Price != -40 {
+		t.Errorf("actual quotes: %#v", quotes)
+	}
+
+	// Quotes expire as they age past the max age, even without new quotes.
+	now = now.Add(25 * time.Minute)
+	quotes, err = c.GetQuotesRange(ctx, "fb",
+		history.Range{Order: history.Ascending})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 2 || quotes[0].Price != -30 || quotes[1].Price != -20 {
+		t.Errorf("actual unexpired quotes: %#v", quotes)
+	}
+
+	now = now.Add(time.Hour)
+	_, err = c.GetQuotesRange(ctx, "fb", history.Range{})
+	if !errors.Is(err, history.ErrNotFound) {
+		t.Errorf("expected ErrNotFound; actual: %v", err)
+	}
+}
+
+func TestSymbolRegistry(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c, err := New(Symbols([]string{"goog", "fb", "nflx"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.SetQuotes(ctx, []finance.Quote{
+		{Price: dec("123.45"), Symbol: "fb"},
+		{Price: dec("234.56"), Symbol: "goog"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Adding a tracked symbol keeps its quotes.
+	for _, symbol := range []string{"NFLX", "fb"} {
+		if err = c.AddSymbol(ctx, symbol); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = c.GetQuotes(ctx, "fb", 1); err != nil {
+		t.Errorf("quotes lost after adding symbols: %v", err)
+	}
+
+	if err = c.RemoveSymbol(ctx, "GOOG"); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.RemoveSymbol(ctx, "goog"); !errors.Is(err, history.ErrNotFound) {
+		t.Errorf("expected ErrNotFound; actual: %v", err)
+	}
+	if err = c.RemoveSymbol(ctx, "nflx"); err != nil {
+		t.Fatal(err)
+	}
+
+	symbols, err := c.Symbols(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"fb"}; !reflect.DeepEqual(symbols, expected) {
+		t.Errorf("actual symbols: %v; expected: %v", symbols, expected)
+	}
+
+	// A removed symbol's quotes remain readable, but it accepts no more.
+	if _, err = c.GetQuotes(ctx, "goog", 1); err != nil {
+		t.Errorf("quotes lost after removing symbol: %v", err)
+	}
+	err = c.SetQuotes(ctx, []finance.Quote{{Price: dec("234.51"), Symbol: "goog",
+		Time: time.Now()}})
+	if err == nil {
+		t.Error("expected an error archiving an untracked symbol's quote")
+	}
+
+	// Snapshots keep the removed symbol's quotes, but forget a removed symbol
+	// that has none.
+	var buf bytes.Buffer
+	if err = c.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	r, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = r.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+	symbols, err = r.Symbols(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"fb"}; !reflect.DeepEqual(symbols, expected) {
+		t.Errorf("actual restored symbols: %v; expected: %v", symbols, expected)
+	}
+	if _, ok := r.quotes["nflx"]; ok {
+		t.Error("restored a removed symbol without quotes")
+	}
+
+	// Tracking the symbol again keeps its quotes.
+	if err = r.AddSymbol(ctx, "goog"); err != nil {
+		t.Fatal(err)
+	}
+	quotes, err := r.GetQuotes(ctx, "goog", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 1 || quotes[0].Price != dec("234.56") {
+		t.Errorf("actual quotes: %#v", quotes)
+	}
+}
+
+// benchmarkSymbols returns a client tracking n symbols, each with a full
+// buffer of quotes.
+func benchmarkSymbols(b *testing.B, n int) (*Client, []finance.Quote) {
+	symbols := make([]string, n)
+	for i := range symbols {
+		symbols[i] = "sym" + strconv.Itoa(i)
+	}
+	c, err := New(Capacity(1000), Symbols(symbols))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	now := time.Now()
+	quotes := make([]finance.Quote, n)
+	for i := 0; i < 1000; i++ {
+		for j, symbol := range symbols {
+			quotes[j] = finance.Quote{
+				Price:  dec("123.45"),
+				Symbol: symbol,
+				Time:   now.Add(time.Duration(i) * time.Second),
+			}
+		}
+		if err := c.SetQuotes(context.Background(), quotes); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	return c, quotes
+}
+
+func BenchmarkSetQuotes(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			c, quotes := benchmarkSymbols(b, n)
+			ctx := context.Background()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				for j := range quotes {
+					quotes[j].Time = quotes[j].Time.Add(time.Second)
+				}
+				if err := c.SetQuotes(ctx, quotes); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetQuotes(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			c, _ := benchmarkSymbols(b, n)
+			ctx := context.Background()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := c.GetQuotes(ctx, "sym0", 100); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
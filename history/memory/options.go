@@ -2,21 +2,66 @@ package memory
 
 import (
 	"strings"
+	"time"
 
-	"github.com/awoodbeck/faang-stonks/finance"
+	"go.uber.org/zap"
 )
 
 type Option func(*Client)
 
+// Capacity sets the maximum number of quotes the Archiver stores for each
+// stock symbol, after which each new quote replaces the oldest. A capacity of
+// zero or less means no limit.
+func Capacity(n int) Option {
+	return func(c *Client) {
+		c.capacity = n
+	}
+}
+
+// Logger sets the logger that reports periodic snapshot failures.
+func Logger(log *zap.SugaredLogger) Option {
+	return func(c *Client) {
+		if log != nil {
+			c.log = log
+		}
+	}
+}
+
+// MaxAge sets the age after which the Archiver discards a quote. A max age of
+// zero or less means quotes don't expire.
+func MaxAge(d time.Duration) Option {
+	return func(c *Client) {
+		c.maxAge = d
+	}
+}
+
+// SnapshotFile sets the file the Archiver periodically snapshots its symbols
+// and quotes to, and restores them from when created.
+func SnapshotFile(file string) Option {
+	return func(c *Client) {
+		c.snapshotFile = file
+	}
+}
+
+// SnapshotInterval sets the duration between snapshots written to the
+// snapshot file. An interval of zero or less disables periodic snapshots,
+// leaving only the snapshot written on Close.
+func SnapshotInterval(d time.Duration) Option {
+	return func(c *Client) {
+		c.snapshotInterval = d
+	}
+}
+
 // Symbols configures the Archiver to track specific stock symbols.
 func Symbols(symbols []string) Option {
 	s := make([]string, len(symbols))
 	copy(s, symbols)
 
 	return func(c *Client) {
-		c.quotes = make(map[string][]finance.Quote)
+		c.quotes = make(map[string]*ring)
+		c.tracked = make(map[string]struct{})
 		for _, symbol := range s {
-			c.quotes[strings.ToLower(symbol)] = []finance.Quote{}
+			c.track(strings.ToLower(symbol))
 		}
 	}
 }
@@ -0,0 +1,28 @@
+package memory
+
+import (
+	"github.com/awoodbeck/faang-stonks/history"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+func init() {
+	history.Register("memory", history.Backend{
+		Flags: func(fs *pflag.FlagSet) {
+			fs.Int("memory-capacity", DefaultCapacity, "max quotes stored per symbol (0 = no limit)")
+			fs.Duration("memory-max-age", 0, "discard quotes older than this duration (0 = never)")
+			fs.String("memory-snapshot-file", "", "file to snapshot quotes to and restore them from")
+			fs.Duration("memory-snapshot-interval", DefaultSnapshotInterval, "duration between snapshots")
+		},
+		New: func(cfg history.Config, log *zap.SugaredLogger) (history.Storage, error) {
+			return New(
+				Capacity(cfg.GetInt("memory-capacity")),
+				Logger(log),
+				MaxAge(cfg.GetDuration("memory-max-age")),
+				SnapshotFile(cfg.GetString("memory-snapshot-file")),
+				SnapshotInterval(cfg.GetDuration("memory-snapshot-interval")),
+				Symbols(cfg.GetStringSlice("symbols")),
+			)
+		},
+	})
+}
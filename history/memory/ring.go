@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+)
+
+// ring is a circular buffer of a symbol's quotes, ordered newest first. It
+// grows as needed up to a capacity, after which each new quote overwrites the
+// oldest, so inserts are O(1) and the newest quotes are at most two
+// contiguous runs of the backing slice.
+type ring struct {
+	buf   []finance.Quote
+	start int // index of the newest quote
+	n     int
+}
+
+// at returns the i-th newest quote.
+func (r *ring) at(i int) finance.Quote {
+	return r.buf[(r.start+i)%len(r.buf)]
+}
+
+// copyTo copies the newest quotes into dst, newest first, and returns the
+// number of quotes copied.
+func (r *ring) copyTo(dst []finance.Quote) int {
+	if len(dst) > r.n {
+		dst = dst[:r.n]
+	}
+
+	n := copy(dst, r.buf[r.start:])
+	n += copy(dst[n:], r.buf)
+
+	return n
+}
+
+// grow enlarges the backing slice, doubling it up to the capacity. A capacity
+// of zero means no limit.
+func (r *ring) grow(capacity int) {
+	size := 2 * len(r.buf)
+	if size < 8 {
+		size = 8
+	}
+	if capacity > 0 && size > capacity {
+		size = capacity
+	}
+
+	buf := make([]finance.Quote, size)
+	r.copyTo(buf)
+	r.buf, r.start = buf, 0
+}
+
+// len returns the number of quotes newer than the cutoff. Quotes older than
+// the cutoff are the oldest, so it stops at the first quote that isn't.
+func (r *ring) len(cutoff time.Time) int {
+	n := r.n
+	for n > 0 && r.at(n-1).Time.Before(cutoff) {
+		n--
+	}
+
+	return n
+}
+
+// push adds the quote as the newest, overwriting the oldest quote if the ring
+// holds capacity quotes.
+func (r *ring) push(q finance.Quote, capacity int) {
+	if r.n == len(r.buf) {
+		if capacity <= 0 || len(r.buf) < capacity {
+			r.grow(capacity)
+		} else {
+			r.n-- // the oldest quote occupies the slot before the newest
+		}
+	}
+
+	r.start = (r.start - 1 + len(r.buf)) % len(r.buf)
+	r.buf[r.start] = q
+	r.n++
+}
+
+// trim drops the quotes older than the cutoff.
+func (r *ring) trim(cutoff time.Time) {
+	for n := r.len(cutoff); r.n > n; r.n-- {
+		r.buf[(r.start+r.n-1)%len(r.buf)] = finance.Quote{}
+	}
+}
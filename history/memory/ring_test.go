@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+)
+
+func TestRing(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	quote := func(i int) finance.Quote {
+		return finance.Quote{
+			Price:  finance.Decimal(i),
+			Symbol: "fb",
+			Time:   now.Add(time.Duration(i) * time.Minute),
+		}
+	}
+
+	testCases := []struct {
+		capacity int
+		pushes   int
+		expected []int // prices, newest first
+	}{
+		{capacity: 0, pushes: 3, expected: []int{2, 1, 0}},
+		{capacity: 0, pushes: 20, expected: []int{19, 18, 17, 16, 15, 14, 13,
+			12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1, 0}},
+		{capacity: 1, pushes: 3, expected: []int{2}},
+		{capacity: 5, pushes: 5, expected: []int{4, 3, 2, 1, 0}},
+		{capacity: 5, pushes: 12, expected: []int{11, 10, 9, 8, 7}},
+		{capacity: 10, pushes: 23, expected: []int{22, 21, 20, 19, 18, 17, 16,
+			15, 14, 13}},
+	}
+
+	for i, tc := range testCases {
+		r := new(ring)
+		for j := 0; j < tc.pushes; j++ {
+			r.push(quote(j), tc.capacity)
+		}
+		if tc.capacity > 0 && len(r.buf) > tc.capacity {
+			t.Errorf("%d: buffer length %d exceeds capacity", i, len(r.buf))
+		}
+
+		actual := make([]int, r.n)
+		for j := range actual {
+			actual[j] = int(r.at(j).Price)
+		}
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("%d: actual: %v; expected: %v", i, actual, tc.expected)
+		}
+
+		out := make([]finance.Quote, len(tc.expected)+1)
+		if n := r.copyTo(out); n != len(tc.expected) {
+			t.Errorf("%d: copied %d quotes; expected %d", i, n,
+				len(tc.expected))
+		}
+		for j, price := range tc.expected {
+			if int(out[j].Price) != price {
+				t.Errorf("%d.%d: actual copied price: %d; expected: %d", i, j,
+					out[j].Price, price)
+			}
+		}
+	}
+
+	// Trimming drops the oldest quotes before the cutoff.
+	r := new(ring)
+	for j := 0; j < 12; j++ {
+		r.push(quote(j), 10)
+	}
+	cutoff := now.Add(5 * time.Minute)
+	if n := r.len(cutoff); n != 7 {
+		t.Errorf("actual quotes after cutoff: %d; expected: 7", n)
+	}
+	r.trim(cutoff)
+	if r.n != 7 || r.at(6).Price != 5 {
+		t.Errorf("actual trimmed quotes: %d, oldest %s", r.n, r.at(r.n-1).Price)
+	}
+}
+
+// BenchmarkInsert compares a ring buffer's inserts with prepending to a
+// slice, as this package did before, for symbols holding many quotes.
+func BenchmarkInsert(b *testing.B) {
+	for _, depth := range []int{100, 1000, DefaultCapacity} {
+		q := finance.Quote{Price: dec("123.45"), Symbol: "fb"}
+
+		b.Run("ring/"+strconv.Itoa(depth), func(b *testing.B) {
+			r := new(ring)
+			for i := 0; i < depth; i++ {
+				r.push(q, depth)
+			}
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				r.push(q, depth)
+			}
+		})
+
+		b.Run("prepend/"+strconv.Itoa(depth), func(b *testing.B) {
+			quotes := make([]finance.Quote, depth)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				quotes = append([]finance.Quote{q}, quotes[:depth-1]...)
+			}
+		})
+	}
+}
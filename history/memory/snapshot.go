@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/awoodbeck/faang-stonks/metrics"
+)
+
+// DefaultSnapshotInterval is the default duration between snapshots written
+// to the snapshot file.
+const DefaultSnapshotInterval = time.Minute
+
+// snapshotVersion is the version of the snapshot format Snapshot writes.
+const snapshotVersion = 1
+
+var ErrInvalidSnapshot = fmt.Errorf("invalid snapshot")
+
+// snapshot is the gob-encoded content of a snapshot. Quotes maps each symbol
+// to its quotes, newest first. Untracked lists the symbols in Quotes that are
+// no longer tracked, but whose quotes remain; the rest are tracked.
+type snapshot struct {
+	Version   int
+	Quotes    map[string][]finance.Quote
+	Untracked []string
+}
+
+// Restore replaces the client's symbols and quotes with those in the
+// snapshot read from r. Quotes beyond the client's capacity or older than
+// its max age are discarded.
+func (c *Client) Restore(r io.Reader) error {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	var s snapshot
+	if err = gob.NewDecoder(zr).Decode(&s); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	if s.Version != snapshotVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidSnapshot,
+			s.Version)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cutoff := c.cutoff()
+	c.quotes = make(map[string]*ring, len(s.Quotes))
+	c.tracked = make(map[string]struct{}, len(s.Quotes))
+	for symbol, quotes := range s.Quotes {
+		buf := new(ring)
+		for i := len(quotes) - 1; i >= 0; i-- {
+			buf.push(quotes[i], c.capacity)
+		}
+		buf.trim(cutoff)
+		c.quotes[symbol] = buf
+		c.tracked[symbol] = struct{}{}
+	}
+	for _, symbol := range s.Untracked {
+		delete(c.tracked, symbol)
+	}
+
+	return nil
+}
+
+// Snapshot writes the client's symbols and quotes to w in a compressed
+// format Restore can read.
+func (c *Client) Snapshot(w io.Writer) error {
+	// Copy the quotes so encoding them doesn't block archiving.
+	s := snapshot{Version: snapshotVersion}
+
+	c.mu.RLock()
+	cutoff := c.cutoff()
+	s.Quotes = make(map[string][]finance.Quote, len(c.quotes))
+	for symbol, buf := range c.quotes {
+		n := buf.len(cutoff)
+		_, tracked := c.tracked[symbol]
+		if !tracked {
+			// Once its quotes expire, an untracked symbol is forgotten.
+			if n == 0 {
+				continue
+			}
+			s.Untracked = append(s.Untracked, symbol)
+		}
+
+		quotes := make([]finance.Quote, n)
+		buf.copyTo(quotes)
+		s.Quotes[symbol] = quotes
+	}
+	c.mu.RUnlock()
+
+	zw := gzip.NewWriter(w)
+	if err := gob.NewEncoder(zw).Encode(s); err != nil {
+		return fmt.Errorf("encoding snapshot: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("compressing snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// restoreFile restores the client from the snapshot file, if it exists.
+func (c *Client) restoreFile() error {
+	f, err := os.Open(c.snapshotFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("opening snapshot: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	if err = c.Restore(f); err != nil {
+		return fmt.Errorf("restoring %q: %w", c.snapshotFile, err)
+	}
+
+	return nil
+}
+
+// snapshotEvery writes a snapshot to the snapshot file at each interval until
+// the client is closed, logging each failed snapshot.
+func (c *Client) snapshotEvery(interval time.Duration) {
+	defer close(c.snapshotDone)
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-t.C:
+			if err := c.writeSnapshotFile(); err != nil {
+				c.log.Errorf("writing snapshot: %v", err)
+				metrics.SnapshotFailures.Inc()
+			}
+		}
+	}
+}
+
+// writeSnapshotFile writes a snapshot to a temporary file alongside the
+// snapshot file, then renames it over the snapshot file, so a crash never
+// leaves a partial snapshot behind.
+func (c *Client) writeSnapshotFile() error {
+	dir, base := filepath.Split(c.snapshotFile)
+	if dir == "" {
+		dir = "."
+	}
+
+	f, err := ioutil.TempFile(dir, base+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating snapshot: %w", err)
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+
+	err = c.Snapshot(f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("writing snapshot: %w", err)
+	}
+
+	if err = os.Rename(f.Name(), c.snapshotFile); err != nil {
+		return fmt.Errorf("renaming snapshot: %w", err)
+	}
+
+	return nil
+}
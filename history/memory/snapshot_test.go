@@ -0,0 +1,224 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/awoodbeck/faang-stonks/history"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	volume := int64(1234567)
+	dayOpen := dec("121.01")
+	quotes := []finance.Quote{
+		{Price: dec("123.45"), Symbol: "fb", Time: now, Provider: "iexcloud",
+			Open: &dayOpen, Volume: &volume},
+		{Price: dec("123.42"), Symbol: "fb", Time: now.Add(time.Minute)},
+		{Price: dec("123.40"), Symbol: "fb", Time: now.Add(2 * time.Minute)},
+		{Price: dec("234.56"), Symbol: "goog", Time: now},
+	}
+
+	c, err := New(Symbols([]string{"fb", "goog", "nflx"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.SetQuotes(ctx, quotes); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err = c.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := buf.Bytes()
+
+	// The restored client replaces its symbols with the snapshot's and keeps
+	// as many quotes as its capacity allows.
+	r, err := New(Capacity(2), Symbols([]string{"aapl"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = r.Restore(bytes.NewReader(snapshot)); err != nil {
+		t.Fatal(err)
+	}
+
+	symbols, err := r.Symbols(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"fb", "goog", "nflx"}; !reflect.DeepEqual(symbols, expected) {
+		t.Errorf("actual symbols: %v; expected: %v", symbols, expected)
+	}
+
+	actual, err := r.GetQuotesBatch(ctx, []string{"fb", "goog"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := finance.QuoteBatch{
+		"fb":   {quotes[2], quotes[1]},
+		"goog": {quotes[3]},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Error("restored quotes do not equal expected")
+		t.Logf("restored: %#v", actual)
+		t.Logf("expected: %#v", expected)
+	}
+
+	// The optional fields survive, too.
+	c, err = New(Symbols([]string{"fb"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Restore(bytes.NewReader(snapshot)); err != nil {
+		t.Fatal(err)
+	}
+	fb, err := c.GetQuotesRange(ctx, "fb",
+		history.Range{Order: history.Ascending, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fb[0], quotes[0]) {
+		t.Errorf("actual: %#v; expected: %#v", fb[0], quotes[0])
+	}
+
+	for _, b := range [][]byte{nil, []byte("not a snapshot"), snapshot[:len(snapshot)/2]} {
+		if err = c.Restore(bytes.NewReader(b)); !errors.Is(err, ErrInvalidSnapshot) {
+			t.Errorf("expected ErrInvalidSnapshot; actual: %v", err)
+		}
+	}
+}
+
+func TestSnapshotFile(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "stonks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Logf("removing temp dir: %v", err)
+		}
+	}()
+
+	ctx := context.Background()
+	file := filepath.Join(dir, "stonks.snapshot")
+	quote := finance.Quote{Price: dec("123.45"), Symbol: "fb",
+		Time: time.Now().UTC()}
+
+	c, err := New(SnapshotFile(file), SnapshotInterval(10*time.Millisecond),
+		Symbols([]string{"fb"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.SetQuotes(ctx, []finance.Quote{quote}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A periodic snapshot lands without closing the client.
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err = os.Stat(file); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no periodic snapshot: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err = c.AddSymbol(ctx, "goog"); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Close(); err != nil {
+		t.Errorf("closing twice: %v", err)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) > 0 {
+		t.Errorf("temporary snapshots left behind: %v", matches)
+	}
+
+	// The final snapshot on close includes changes since the last periodic
+	// snapshot.
+	c, err = New(SnapshotFile(file), SnapshotInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = c.Close() }()
+
+	symbols, err := c.Symbols(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"fb", "goog"}; !reflect.DeepEqual(symbols, expected) {
+		t.Errorf("actual symbols: %v; expected: %v", symbols, expected)
+	}
+	quotes, err := c.GetQuotes(ctx, "fb", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(quotes, []finance.Quote{quote}) {
+		t.Errorf("actual quotes: %#v", quotes)
+	}
+
+	if err = ioutil.WriteFile(file, []byte("corrupt"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, err = New(SnapshotFile(file))
+	if !errors.Is(err, ErrInvalidSnapshot) {
+		t.Errorf("expected ErrInvalidSnapshot; actual: %v", err)
+	}
+}
+
+func TestSnapshotFailureLogged(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "stonks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Logf("removing temp dir: %v", err)
+		}
+	}()
+
+	core, logs := observer.New(zapcore.ErrorLevel)
+	c, err := New(SnapshotFile(filepath.Join(dir, "gone", "stonks.snapshot")),
+		SnapshotInterval(10*time.Millisecond), Logger(zap.New(core).Sugar()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The snapshot file's directory doesn't exist, so no snapshot can land.
+	deadline := time.Now().Add(time.Second)
+	for logs.FilterMessageSnippet("snapshot").Len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("snapshot failure not logged")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	_ = c.Close()
+}
@@ -5,11 +5,65 @@ package history
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/awoodbeck/faang-stonks/finance"
 )
 
-var ErrNotFound = fmt.Errorf("not found")
+var (
+	ErrInvalidRange = fmt.Errorf("invalid range")
+	ErrNotFound     = fmt.Errorf("not found")
+)
+
+// SortOrder describes the order in which quotes are returned to the caller.
+type SortOrder int
+
+const (
+	// Descending returns the newest quotes first. This is the default.
+	Descending SortOrder = iota
+
+	// Ascending returns the oldest quotes first.
+	Ascending
+)
+
+// Range describes a window of time over which to retrieve quotes. From is
+// inclusive and To is exclusive. A zero From or To leaves that end of the
+// window unbounded. A Limit less than 1 returns every quote in the window.
+type Range struct {
+	From  time.Time
+	To    time.Time
+	Limit int
+	Order SortOrder
+}
+
+// Contains returns true if the given time falls within the range.
+func (r Range) Contains(t time.Time) bool {
+	if !r.From.IsZero() && t.Before(r.From) {
+		return false
+	}
+	if !r.To.IsZero() && !t.Before(r.To) {
+		return false
+	}
+
+	return true
+}
+
+// Validate returns ErrInvalidRange if the range's bounds are inverted or its
+// sort order is unknown.
+func (r Range) Validate() error {
+	if !r.From.IsZero() && !r.To.IsZero() && !r.To.After(r.From) {
+		return fmt.Errorf("%w: %q is not after %q", ErrInvalidRange,
+			r.To.Format(time.RFC3339), r.From.Format(time.RFC3339))
+	}
+
+	switch r.Order {
+	case Ascending, Descending:
+	default:
+		return fmt.Errorf("%w: unknown sort order %d", ErrInvalidRange, r.Order)
+	}
+
+	return nil
+}
 
 // Provider describes an object that can retrieve requested stock quotes.
 type Provider interface {
@@ -25,4 +79,16 @@ type Provider interface {
 	// number of archived quotes. The default symbol list is used in the
 	// absence of a populated symbols slice.
 	GetQuotesBatch(ctx context.Context, symbols []string, last int) (finance.QuoteBatch, error)
+
+	// GetQuotesRange accepts a context for cancellation support, a stock
+	// symbol, and a range. It returns up to r.Limit quotes that fall within
+	// the range in the requested order, or ErrNotFound if no quotes do.
+	GetQuotesRange(ctx context.Context, symbol string, r Range) ([]finance.Quote, error)
+
+	// GetQuotesBatchRange accepts a context for cancellation support, stock
+	// symbols, and a range. It returns up to r.Limit quotes per symbol that
+	// fall within the range in the requested order, or ErrNotFound if no
+	// quotes do. The default symbol list is used in the absence of a
+	// populated symbols slice.
+	GetQuotesBatchRange(ctx context.Context, symbols []string, r Range) (finance.QuoteBatch, error)
 }
@@ -0,0 +1,108 @@
+package history
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrUnknownBackend = fmt.Errorf("unknown storage backend")
+
+	backendsMu sync.RWMutex
+	backends   = make(map[string]Backend)
+)
+
+// Storage describes an object that can both archive and provide stock quotes.
+type Storage interface {
+	Archiver
+	Provider
+}
+
+// Config describes the source of a storage backend's settings. A *viper.Viper
+// satisfies this interface.
+type Config interface {
+	GetBool(key string) bool
+	GetDuration(key string) time.Duration
+	GetInt(key string) int
+	GetString(key string) string
+	GetStringSlice(key string) []string
+	GetUint(key string) uint
+}
+
+// Backend describes a storage backend that can be selected by name at
+// runtime.
+type Backend struct {
+	// Flags adds the backend's settings to the given flag set. Flag names
+	// should be prefixed with the backend's name to avoid collisions. It may
+	// be nil if the backend has no settings of its own.
+	Flags func(fs *pflag.FlagSet)
+
+	// New returns a new Storage object configured from the given settings,
+	// which include the backend's flags. The logger reports errors from any
+	// background work the backend does, such as compaction.
+	New func(cfg Config, log *zap.SugaredLogger) (Storage, error)
+}
+
+// Register makes a storage backend available by the given name. Backends
+// typically register themselves in an init function. Register panics if it's
+// called twice for the same name or if the backend's New function is nil.
+func Register(name string, b Backend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	if b.New == nil {
+		panic(fmt.Sprintf("history: nil New for storage backend %q", name))
+	}
+	if _, ok := backends[name]; ok {
+		panic(fmt.Sprintf("history: storage backend %q registered twice", name))
+	}
+
+	backends[name] = b
+}
+
+// Backends returns the sorted names of the registered storage backends.
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// AddFlags adds the settings of every registered storage backend to the given
+// flag set.
+func AddFlags(fs *pflag.FlagSet) {
+	for _, name := range Backends() {
+		backendsMu.RLock()
+		f := backends[name].Flags
+		backendsMu.RUnlock()
+
+		if f != nil {
+			f(fs)
+		}
+	}
+}
+
+// New returns a new Storage object from the named backend, configured from
+// the given settings, that logs to the given logger.
+func New(name string, cfg Config, log *zap.SugaredLogger) (Storage, error) {
+	backendsMu.RLock()
+	b, ok := backends[name]
+	backendsMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownBackend, name)
+	}
+
+	return b.New(cfg, log)
+}
@@ -0,0 +1,68 @@
+package history
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+type testConfig map[string]string
+
+func (c testConfig) GetBool(string) bool              { return false }
+func (c testConfig) GetDuration(string) time.Duration { return 0 }
+func (c testConfig) GetInt(string) int                { return 0 }
+func (c testConfig) GetString(key string) string      { return c[key] }
+func (c testConfig) GetStringSlice(string) []string   { return nil }
+func (c testConfig) GetUint(string) uint              { return 0 }
+
+func TestRegistry(t *testing.T) {
+	var configured string
+	Register("test", Backend{
+		Flags: func(fs *pflag.FlagSet) {
+			fs.String("test-setting", "default", "test setting")
+		},
+		New: func(cfg Config, _ *zap.SugaredLogger) (Storage, error) {
+			configured = cfg.GetString("test-setting")
+			return nil, nil
+		},
+	})
+
+	found := false
+	for _, name := range Backends() {
+		found = found || name == "test"
+	}
+	if !found {
+		t.Errorf("backend not listed: %v", Backends())
+	}
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	AddFlags(fs)
+	if f := fs.Lookup("test-setting"); f == nil || f.DefValue != "default" {
+		t.Errorf("backend flag not added: %v", f)
+	}
+
+	_, err := New("test", testConfig{"test-setting": "configured"}, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configured != "configured" {
+		t.Errorf("actual setting: %q; expected: %q", configured, "configured")
+	}
+
+	_, err = New("nonexistent", testConfig{}, zap.NewNop().Sugar())
+	if !errors.Is(err, ErrUnknownBackend) {
+		t.Errorf("expected ErrUnknownBackend; actual: %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a backend twice did not panic")
+		}
+	}()
+	Register("test", Backend{New: func(Config, *zap.SugaredLogger) (Storage, error) {
+		return nil, nil
+	}})
+}
@@ -5,14 +5,16 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/awoodbeck/faang-stonks/finance"
 	"github.com/awoodbeck/faang-stonks/history"
+	"github.com/awoodbeck/faang-stonks/metrics"
 	_ "github.com/mattn/go-sqlite3"
+	"go.uber.org/zap"
 )
 
 const (
@@ -27,25 +29,18 @@ const (
 	// remain idle.
 	DefaultMaxIdleConns = 2
 
-	// TODO: I can make an argument for and against normalizing the symbols
-	// column. I'll keep it as-is for the purposes of this demo.
-	createQuotesTable = `
-CREATE TABLE "quotes"
-(
-	id integer not null
-		constraint quotes_pk
-			primary key autoincrement,
-	symbol text not null,
-	price real not null,
-	datetime timestamp not null
-)`
+	// quoteColumns are the quote columns scanQuote expects, in order.
+	quoteColumns = `symbol, price, datetime, provider, source,
+  calculation_price, open, high, low, close, volume, previous_close, change,
+  change_percent, market_cap, pe_ratio, week52_high, week52_low`
 
+	// the unique index on symbol and datetime ignores quotes already stored
 	insertQuote = `
-INSERT INTO quotes (symbol, price, datetime)
-  VALUES (?, ?, ?)`
+INSERT OR IGNORE INTO quotes (` + quoteColumns + `)
+  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	selectQuotes = `
-SELECT symbol, price, datetime
+SELECT ` + quoteColumns + `
   FROM quotes
   WHERE symbol = ?
   ORDER BY id DESC
@@ -55,56 +50,168 @@ SELECT symbol, price, datetime
 	// when batching
 	selectQuotesBatch = `
 WITH summary AS (
-  SELECT q.symbol, q.price, q.datetime, ROW_NUMBER()
+  SELECT q.*, ROW_NUMBER()
     OVER(PARTITION BY q.symbol
     ORDER BY q.id DESC) AS rank
   FROM quotes q
 )
-SELECT s.*
+SELECT ` + quoteColumns + `, rank
 FROM summary s
 WHERE symbol IN (XXX)
   AND s.rank <= ?`
+
+	// bucket each quote by its Unix time, then take the first and last
+	// price in each bucket as the open and close, respectively
+	selectCandles = `
+WITH bucketed AS (
+  SELECT id, price, datetime,
+    CAST(strftime('%s', datetime) AS INTEGER) / ? * ? AS bucket
+  FROM quotes
+  WHERE symbol = ?RANGE
+), windowed AS (
+  SELECT bucket, price,
+    FIRST_VALUE(price) OVER w AS open,
+    LAST_VALUE(price) OVER w AS close
+  FROM bucketed
+  WINDOW w AS (PARTITION BY bucket ORDER BY datetime, id
+    ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING)
+)
+SELECT bucket, open, MAX(price), MIN(price), close, COUNT(*)
+FROM windowed
+GROUP BY bucket
+ORDER BY bucket DIR
+LIMIT ?`
+
+	selectQuotesRange = `
+SELECT ` + quoteColumns + `
+  FROM quotes
+  WHERE symbol = ?RANGE
+  ORDER BY datetime DIR, id DIR
+  LIMIT ?`
+
+	insertSymbol = `
+INSERT OR IGNORE INTO symbols (symbol, added_at)
+  VALUES (?, ?)`
+
+	deleteSymbol = `
+DELETE FROM symbols
+  WHERE symbol = ?`
+
+	selectSymbols = `
+SELECT symbol
+  FROM symbols
+  ORDER BY symbol`
+
+	// same partitioning as selectQuotesBatch, but bounded by time and
+	// ranked in the requested order
+	selectQuotesBatchRange = `
+WITH summary AS (
+  SELECT q.*, ROW_NUMBER()
+    OVER(PARTITION BY q.symbol
+    ORDER BY q.datetime DIR, q.id DIR) AS rank
+  FROM quotes q
+  WHERE q.symbol IN (XXX)RANGE
+)
+SELECT ` + quoteColumns + `, rank
+FROM summary s
+WHERE ? < 1
+  OR s.rank <= ?
+ORDER BY s.symbol, s.rank`
 )
 
 var (
-	_ history.Archiver = (*Client)(nil)
-	_ history.Provider = (*Client)(nil)
+	_ history.Archiver       = (*Client)(nil)
+	_ history.CandleProvider = (*Client)(nil)
+	_ history.Provider       = (*Client)(nil)
+	_ history.SymbolRegistry = (*Client)(nil)
 )
 
 // Client implements the history.Archiver and history.Provider interfaces,
-// knowing how to store and retrieve stock quotes, respectively.
+// knowing how to store and retrieve stock quotes, respectively. It also
+// implements the history.SymbolRegistry interface, persisting the tracked
+// symbols alongside their quotes.
 type Client struct {
 	db               *sql.DB
+	autoMigrate      bool
 	file             string
 	maxIdleConns     int
 	connsMaxLifetime time.Duration
+	log              *zap.SugaredLogger
 	symbols          map[string]struct{}
+
+	retention       time.Duration
+	tiers           []Tier
+	compactInterval time.Duration
+	now             func() time.Time
+	stopCompacting  context.CancelFunc
+	compacted       chan struct{}
 }
 
-// initialize the database file.
+// initialize the database file, creating it if necessary, and bring its
+// schema up to date unless automatic migrations are disabled.
 func (c *Client) initialize() error {
-	// TODO: Add proper support for data migrations. For demo purposes, we
-	// start with a fresh database upon each invocation of this application.
-	err := os.Remove(c.file)
-	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("removing %q: %w", c.file, err)
-	}
+	var err error
 
 	c.db, err = sql.Open("sqlite3", c.file)
 	if err != nil {
 		return fmt.Errorf("open %q: %w", c.file, err)
 	}
 
-	_, err = c.db.Exec(createQuotesTable)
+	if !c.autoMigrate {
+		return nil
+	}
+
+	_, err = c.Migrate(context.Background())
+	if err != nil {
+		_ = c.db.Close()
+		return fmt.Errorf("migrating %q: %w", c.file, err)
+	}
+
+	err = c.seedSymbols(context.Background())
 	if err != nil {
-		return fmt.Errorf("creating quotes table: %w", err)
+		_ = c.db.Close()
+		return fmt.Errorf("seeding symbols in %q: %w", c.file, err)
 	}
 
 	return nil
 }
 
-// Close the database connection.
+// seedSymbols registers the client's configured symbols if no symbols are
+// registered, as is the case for a new database. Otherwise, the registered
+// symbols take precedence.
+func (c *Client) seedSymbols(ctx context.Context) error {
+	symbols, err := c.Symbols(ctx)
+	if err != nil || len(symbols) > 0 {
+		return err
+	}
+
+	for symbol := range c.symbols {
+		if err = c.AddSymbol(ctx, symbol); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddSymbol registers the stock symbol, if it isn't already registered.
+func (c Client) AddSymbol(ctx context.Context, symbol string) error {
+	_, err := c.db.ExecContext(ctx, insertSymbol, strings.ToLower(symbol),
+		time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("inserting symbol %q: %w", symbol, err)
+	}
+
+	return nil
+}
+
+// Close the database connection, after stopping background compaction.
 func (c Client) Close() error {
+	if c.stopCompacting != nil {
+		c.stopCompacting()
+		<-c.compacted
+	}
+
 	if c.db == nil {
 		return nil
 	}
@@ -112,8 +219,117 @@ func (c Client) Close() error {
 	return c.db.Close()
 }
 
+// GetCandles accepts a stock symbol, an interval, and a range, and returns
+// the candles aggregated from the stock's quotes that fall within the range.
+// If the client rolls old quotes up into retention tiers, candles older than
+// the quotes it keeps come from the finest tier whose interval divides the
+// requested interval and still covers their time.
+func (c Client) GetCandles(ctx context.Context, symbol string,
+	interval time.Duration, r history.Range) ([]history.Candle, error) {
+	if err := history.ValidateInterval(interval); err != nil {
+		return nil, err
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	symbol = strings.ToLower(symbol)
+	sources := c.candleSources(interval)
+
+	var (
+		candles []history.Candle
+		until   time.Time // the start of the previous, newer source
+	)
+	for _, src := range sources {
+		sub, ok := clip(r, src.from, until)
+		until = src.from
+		if !ok {
+			continue
+		}
+
+		var (
+			out []history.Candle
+			err error
+		)
+		if src.interval == 0 {
+			out, err = c.getRawCandles(ctx, symbol, interval, sub)
+		} else {
+			out, err = c.getTierCandles(ctx, symbol, src.interval, interval, sub)
+		}
+		if err != nil {
+			return nil, err
+		}
+		candles = append(candles, out...)
+	}
+
+	if len(candles) == 0 {
+		return nil, history.ErrNotFound
+	}
+	if len(sources) > 1 {
+		candles = mergeCandles(candles, r)
+	}
+
+	return candles, nil
+}
+
+// getRawCandles returns the candles of the given interval aggregated from the
+// stock's quotes that fall within the range.
+func (c Client) getRawCandles(ctx context.Context, symbol string,
+	interval time.Duration, r history.Range) ([]history.Candle, error) {
+	clause, args := rangeClause(r, "datetime")
+
+	seconds := int64(interval / time.Second)
+	args = append([]interface{}{seconds, seconds, symbol}, args...)
+	args = append(args, limit(r))
+
+	return c.queryCandles(ctx, symbol, rangeQuery(selectCandles, clause, r),
+		args...)
+}
+
+// queryCandles runs the candle query, which selects each candle's bucket,
+// open, high, low, close, and count, and returns the candles.
+func (c Client) queryCandles(ctx context.Context, symbol, query string,
+	args ...interface{}) ([]history.Candle, error) {
+	stmt, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("selecting candles: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select query candles: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var candles []history.Candle
+
+	for rows.Next() {
+		var (
+			candle = history.Candle{Symbol: symbol}
+			bucket int64
+		)
+		err = rows.Scan(&bucket, &candle.Open, &candle.High, &candle.Low,
+			&candle.Close, &candle.Count)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+		candle.Time = time.Unix(bucket, 0).UTC()
+
+		candles = append(candles, candle)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return candles, nil
+}
+
 // GetQuotes accepts a stock symbol and the latest quotes for the stock to
-// return.
+// return. If the client keeps retention tiers and has fewer quotes than
+// requested, the rest come from the tiers, as GetQuotesRange returns them.
 func (c Client) GetQuotes(ctx context.Context, symbol string, last int) (
 	[]finance.Quote, error) {
 	stmt, err := c.db.Prepare(selectQuotes)
@@ -135,15 +351,10 @@ func (c Client) GetQuotes(ctx context.Context, symbol string, last int) (
 	quotes := make([]finance.Quote, 0, last)
 
 	for rows.Next() {
-		var (
-			q finance.Quote
-			t time.Time
-		)
-		err = rows.Scan(&q.Symbol, &q.Price, &t)
+		q, err := scanQuote(rows)
 		if err != nil {
 			return nil, fmt.Errorf("row scan: %w", err)
 		}
-		q.Time = t.UTC()
 
 		quotes = append(quotes, q)
 	}
@@ -153,6 +364,9 @@ func (c Client) GetQuotes(ctx context.Context, symbol string, last int) (
 		return nil, fmt.Errorf("rows error: %w", err)
 	}
 
+	if len(quotes) < last && c.tiered() {
+		return c.GetQuotesRange(ctx, symbol, history.Range{Limit: last})
+	}
 	if len(quotes) == 0 {
 		return nil, history.ErrNotFound
 	}
@@ -161,7 +375,8 @@ func (c Client) GetQuotes(ctx context.Context, symbol string, last int) (
 }
 
 // GetQuotesBatch accepts a slice of symbols and an integer indicating the last
-// N quotes per symbol to return to the caller.
+// N quotes per symbol to return to the caller. Like GetQuotes, it reads the
+// retention tiers for symbols with fewer quotes than requested.
 func (c *Client) GetQuotesBatch(ctx context.Context, symbols []string,
 	last int) (finance.QuoteBatch, error) {
 
@@ -195,16 +410,212 @@ func (c *Client) GetQuotesBatch(ctx context.Context, symbols []string,
 	batch := make(finance.QuoteBatch)
 
 	for rows.Next() {
+		var rank int
+		q, err := scanQuote(rows, &rank)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+
+		batch[q.Symbol] = append(batch[q.Symbol], q)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	if c.tiered() {
+		for _, symbol := range symbols {
+			if len(batch[strings.ToLower(symbol)]) < last {
+				return c.GetQuotesBatchRange(ctx, symbols,
+					history.Range{Limit: last})
+			}
+		}
+	}
+	if len(batch) == 0 {
+		return nil, history.ErrNotFound
+	}
+
+	return batch, nil
+}
+
+// GetQuotesRange accepts a stock symbol and a range, and returns the quotes
+// for the stock that fall within the range. If the client rolls old quotes up
+// into retention tiers, the time before the quotes it keeps is covered by the
+// finest tier that still covers it, with a quote for each of the tier's
+// candles: its close, timestamped at the start of the candle and with a
+// source naming the tier (e.g., "5m0s candle").
+func (c Client) GetQuotesRange(ctx context.Context, symbol string,
+	r history.Range) ([]finance.Quote, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	symbol = strings.ToLower(symbol)
+	sources := c.quoteSources()
+
+	// Read the sources in the range's order, so the quotes are sorted and
+	// the limit is met by the first sources that have enough quotes.
+	type read struct {
+		interval time.Duration
+		r        history.Range
+	}
+	var (
+		reads []read
+		until time.Time // the start of the previous, newer source
+	)
+	for _, src := range sources {
+		if sub, ok := clip(r, src.from, until); ok {
+			reads = append(reads, read{interval: src.interval, r: sub})
+		}
+		until = src.from
+	}
+	if r.Order == history.Ascending {
+		for i, j := 0, len(reads)-1; i < j; i, j = i+1, j-1 {
+			reads[i], reads[j] = reads[j], reads[i]
+		}
+	}
+
+	var quotes []finance.Quote
+	for _, rd := range reads {
+		if r.Limit > 0 {
+			rd.r.Limit = r.Limit - len(quotes)
+		}
+
 		var (
-			q finance.Quote
-			t time.Time
-			r int
+			out []finance.Quote
+			err error
 		)
-		err = rows.Scan(&q.Symbol, &q.Price, &t, &r)
+		if rd.interval == 0 {
+			out, err = c.getRawQuotes(ctx, symbol, rd.r)
+		} else {
+			out, err = c.getTierQuotes(ctx, symbol, rd.interval, rd.r)
+		}
+		if err != nil {
+			return nil, err
+		}
+		quotes = append(quotes, out...)
+
+		if r.Limit > 0 && len(quotes) >= r.Limit {
+			break
+		}
+	}
+
+	if len(quotes) == 0 {
+		return nil, history.ErrNotFound
+	}
+
+	return quotes, nil
+}
+
+// getRawQuotes returns the stock's archived quotes that fall within the range.
+func (c Client) getRawQuotes(ctx context.Context, symbol string,
+	r history.Range) ([]finance.Quote, error) {
+	clause, args := rangeClause(r, "datetime")
+	stmt, err := c.db.PrepareContext(ctx, rangeQuery(selectQuotesRange, clause, r))
+	if err != nil {
+		return nil, fmt.Errorf("selecting quotes range: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	args = append([]interface{}{symbol}, args...)
+	args = append(args, limit(r))
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select query range: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var quotes []finance.Quote
+
+	for rows.Next() {
+		q, err := scanQuote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+
+		quotes = append(quotes, q)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return quotes, nil
+}
+
+// GetQuotesBatchRange accepts a slice of symbols and a range, and returns the
+// quotes for each symbol that fall within the range. The registered symbols
+// are used if the symbols slice is empty. Ranges that reach back before the
+// quotes the client keeps read the retention tiers, as GetQuotesRange does.
+func (c Client) GetQuotesBatchRange(ctx context.Context, symbols []string,
+	r history.Range) (finance.QuoteBatch, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	if len(symbols) == 0 {
+		var err error
+		symbols, err = c.Symbols(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(symbols) == 0 {
+		return nil, history.ErrNotFound
+	}
+
+	if c.tiered() && r.From.Before(c.now().Add(-c.retention)) {
+		batch := make(finance.QuoteBatch)
+		for _, symbol := range symbols {
+			quotes, err := c.GetQuotesRange(ctx, symbol, r)
+			switch {
+			case errors.Is(err, history.ErrNotFound):
+				continue
+			case err != nil:
+				return nil, err
+			}
+			batch[strings.ToLower(symbol)] = quotes
+		}
+		if len(batch) == 0 {
+			return nil, history.ErrNotFound
+		}
+
+		return batch, nil
+	}
+
+	clause, rangeArgs := rangeClause(r, "q.datetime")
+	q := fmt.Sprintf("?%s", strings.Repeat(", ?", len(symbols)-1))
+	stmt, err := c.db.PrepareContext(ctx, rangeQuery(
+		strings.Replace(selectQuotesBatchRange, "XXX", q, 1), clause, r))
+	if err != nil {
+		return nil, fmt.Errorf("selecting quotes batch range: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	args := make([]interface{}, 0, len(symbols)+len(rangeArgs)+2)
+	for _, symbol := range symbols {
+		args = append(args, strings.ToLower(symbol))
+	}
+	args = append(args, rangeArgs...)
+	args = append(args, r.Limit, r.Limit)
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select query batch range: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	batch := make(finance.QuoteBatch)
+
+	for rows.Next() {
+		var rank int
+		q, err := scanQuote(rows, &rank)
 		if err != nil {
 			return nil, fmt.Errorf("row scan: %w", err)
 		}
-		q.Time = t.UTC()
 
 		batch[q.Symbol] = append(batch[q.Symbol], q)
 	}
@@ -221,8 +632,26 @@ func (c *Client) GetQuotesBatch(ctx context.Context, symbols []string,
 	return batch, nil
 }
 
+// RemoveSymbol unregisters the stock symbol. Its archived quotes remain.
+func (c Client) RemoveSymbol(ctx context.Context, symbol string) error {
+	res, err := c.db.ExecContext(ctx, deleteSymbol, strings.ToLower(symbol))
+	if err != nil {
+		return fmt.Errorf("deleting symbol %q: %w", symbol, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("deleting symbol %q: %w", symbol, err)
+	}
+	if n == 0 {
+		return history.ErrNotFound
+	}
+
+	return nil
+}
+
 // SetQuotes accepts a slice of finance.Quote objects and archives them to
-// SQLite.
+// SQLite. It skips quotes whose symbol and timestamp are already stored.
 func (c Client) SetQuotes(ctx context.Context, quotes []finance.Quote) error {
 	tx, err := c.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -235,33 +664,143 @@ func (c Client) SetQuotes(ctx context.Context, quotes []finance.Quote) error {
 	}
 	defer func() { _ = stmt.Close() }()
 
+	var duplicates int
 	for _, q := range quotes {
-		_, err = stmt.Exec(strings.ToLower(q.Symbol), q.Price, q.Time.UTC())
+		res, err := stmt.Exec(strings.ToLower(q.Symbol), q.Price, q.Time.UTC(),
+			q.Provider, q.Source, q.CalculationPrice, q.Open, q.High, q.Low,
+			q.Close, q.Volume, q.PreviousClose, q.Change, q.ChangePercent,
+			q.MarketCap, q.PERatio, q.Week52High, q.Week52Low)
 		if err != nil {
 			return fmt.Errorf("inserting %v: %w", q, err)
 		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			duplicates++
+		}
 	}
 	err = tx.Commit()
 	if err != nil {
 		return fmt.Errorf("committing transaction: %w", err)
 	}
+	metrics.DuplicateQuotes.WithLabelValues("sqlite").Add(float64(duplicates))
 
 	return nil
 }
 
+// Symbols returns the registered stock symbols in alphabetical order.
+func (c Client) Symbols(ctx context.Context) ([]string, error) {
+	rows, err := c.db.QueryContext(ctx, selectSymbols)
+	if err != nil {
+		return nil, fmt.Errorf("select query symbols: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var symbols []string
+
+	for rows.Next() {
+		var symbol string
+		if err = rows.Scan(&symbol); err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+
+		symbols = append(symbols, symbol)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return symbols, nil
+}
+
+// scanQuote scans the quote columns of the current row into a quote, followed
+// by any extra columns. NULL daily statistics scan to nil.
+func scanQuote(rows *sql.Rows, extra ...interface{}) (finance.Quote, error) {
+	var (
+		q finance.Quote
+		t time.Time
+	)
+
+	dest := []interface{}{&q.Symbol, &q.Price, &t, &q.Provider, &q.Source,
+		&q.CalculationPrice, &q.Open, &q.High, &q.Low, &q.Close, &q.Volume,
+		&q.PreviousClose, &q.Change, &q.ChangePercent, &q.MarketCap,
+		&q.PERatio, &q.Week52High, &q.Week52Low}
+	err := rows.Scan(append(dest, extra...)...)
+	q.Time = t.UTC()
+
+	return q, err
+}
+
+// limit returns the range's limit in a form suitable for a SQLite LIMIT
+// clause, where a negative value means no limit.
+func limit(r history.Range) int {
+	if r.Limit < 1 {
+		return -1
+	}
+
+	return r.Limit
+}
+
+// rangeClause returns the SQL conditions, and their arguments, that bound the
+// given column to the range. Timestamps are compared in UTC, which is how
+// SetQuotes stores them.
+func rangeClause(r history.Range, column string) (string, []interface{}) {
+	var (
+		clause string
+		args   []interface{}
+	)
+
+	if !r.From.IsZero() {
+		clause += fmt.Sprintf("\n  AND %s >= ?", column)
+		args = append(args, r.From.UTC())
+	}
+	if !r.To.IsZero() {
+		clause += fmt.Sprintf("\n  AND %s < ?", column)
+		args = append(args, r.To.UTC())
+	}
+
+	return clause, args
+}
+
+// rangeQuery substitutes the range clause and sort order into the query.
+func rangeQuery(query, clause string, r history.Range) string {
+	order := "DESC"
+	if r.Order == history.Ascending {
+		order = "ASC"
+	}
+
+	query = strings.Replace(query, "RANGE", clause, 1)
+
+	return strings.ReplaceAll(query, "DIR", order)
+}
+
 // New returns a pointer to a new Client object after applying optional settings.
 //
+// The database file is created if it doesn't exist, and any pending schema
+// migrations are applied to it. If the database has no registered symbols,
+// the configured symbols are registered. If the client has a retention, it
+// compacts the database in the background, starting right away, unless
+// automatic migrations are disabled.
+//
 // Defaults:
+//     AutoMigrate        = true
+//     CompactionInterval = 1 hour
 //     ConnMaxLifetime    = -1 (no max lifetime)
 //     DatabaseFile       = "stonks.sqlite"
+//     Logger             = no-op logger
 //     MaxIdleConnections = 2
+//     Retention          = 0 (keep quotes forever, without tiers)
 //     Symbols            = default symbols from finance package
 func New(options ...Option) (*Client, error) {
 	c := &Client{
+		autoMigrate:      true,
 		file:             DefaultDatabaseFile,
 		connsMaxLifetime: -1,
 		maxIdleConns:     2,
 		symbols:          make(map[string]struct{}),
+		compactInterval:  DefaultCompactionInterval,
+		log:              zap.NewNop().Sugar(),
+		now:              time.Now,
 	}
 
 	for _, symbol := range finance.DefaultSymbols {
@@ -272,6 +811,12 @@ func New(options ...Option) (*Client, error) {
 		option(c)
 	}
 
+	if c.retention > 0 {
+		if err := validateTiers(c.retention, c.tiers); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := c.initialize(); err != nil {
 		return nil, err
 	}
@@ -279,5 +824,12 @@ func New(options ...Option) (*Client, error) {
 	c.db.SetConnMaxLifetime(c.connsMaxLifetime)
 	c.db.SetMaxIdleConns(c.maxIdleConns)
 
+	if c.retention > 0 && c.compactInterval > 0 && c.autoMigrate {
+		var ctx context.Context
+		ctx, c.stopCompacting = context.WithCancel(context.Background())
+		c.compacted = make(chan struct{})
+		go c.compactEvery(ctx, c.compactInterval)
+	}
+
 	return c, nil
 }
@@ -2,15 +2,21 @@ package sqlite
 
 import (
 	"context"
+	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/awoodbeck/faang-stonks/history"
 )
 
+// dec parses a Decimal constant, such as a price.
+var dec = finance.MustParseDecimal
+
 func TestGetQuotes(t *testing.T) {
 	t.Parallel()
 
@@ -23,13 +29,13 @@ func TestGetQuotes(t *testing.T) {
 	}{
 		{ // add multiple quotes but return the last one added
 			quotes: []finance.Quote{
-				{Price: 123.45, Symbol: "fb", Time: now},
-				{Price: 123.42, Symbol: "fb", Time: now},
+				{Price: dec("123.45"), Symbol: "fb", Time: now},
+				{Price: dec("123.42"), Symbol: "fb", Time: now.Add(time.Second)},
 			},
 			symbol: "fb",
 			last:   0,
 			expected: []finance.Quote{
-				{Price: 123.42, Symbol: "fb", Time: now},
+				{Price: dec("123.42"), Symbol: "fb", Time: now},
 			},
 		},
 	}
@@ -72,7 +78,7 @@ func TestGetQuotes(t *testing.T) {
 
 		for j, q := range actual {
 			if q.Price != tc.expected[i].Price {
-				t.Errorf("%d.%d: actual price: %.2f; expected: %.2f", i, j,
+				t.Errorf("%d.%d: actual price: %s; expected: %s", i, j,
 					q.Price, tc.expected[i].Price)
 			}
 			if q.Symbol != tc.expected[i].Symbol {
@@ -95,22 +101,22 @@ func TestGetQuotesBatch(t *testing.T) {
 	}{
 		{ // add multiple quotes but return the last one added
 			quotes: []finance.Quote{
-				{Price: 123.45, Symbol: "fb", Time: now},
-				{Price: 123.42, Symbol: "fb", Time: now},
-				{Price: 123.40, Symbol: "fb", Time: now},
-				{Price: 234.56, Symbol: "goog", Time: now},
-				{Price: 234.51, Symbol: "goog", Time: now},
+				{Price: dec("123.45"), Symbol: "fb", Time: now},
+				{Price: dec("123.42"), Symbol: "fb", Time: now.Add(time.Second)},
+				{Price: dec("123.40"), Symbol: "fb", Time: now.Add(2 * time.Second)},
+				{Price: dec("234.56"), Symbol: "goog", Time: now},
+				{Price: dec("234.51"), Symbol: "goog", Time: now.Add(time.Second)},
 			},
 			symbols: []string{"fb", "goog"},
 			last:    2,
 			expected: finance.QuoteBatch{
 				"fb": {
-					{Price: 123.40, Symbol: "fb"},
-					{Price: 123.42, Symbol: "fb"},
+					{Price: dec("123.40"), Symbol: "fb"},
+					{Price: dec("123.42"), Symbol: "fb"},
 				},
 				"goog": {
-					{Price: 234.51, Symbol: "goog"},
-					{Price: 234.56, Symbol: "goog"},
+					{Price: dec("234.51"), Symbol: "goog"},
+					{Price: dec("234.56"), Symbol: "goog"},
 				},
 			},
 		},
@@ -158,7 +164,7 @@ func TestGetQuotesBatch(t *testing.T) {
 		for symbol := range actual {
 			for j, q := range actual[symbol] {
 				if q.Price != tc.expected[symbol][j].Price {
-					t.Errorf("actual price: %.2f; expected: %.2f", q.Price,
+					t.Errorf("actual price: %s; expected: %s", q.Price,
 						tc.expected[symbol][j].Price)
 				}
 				if q.Symbol != tc.expected[symbol][j].Symbol {
@@ -169,3 +175,390 @@ func TestGetQuotesBatch(t *testing.T) {
 		}
 	}
 }
+
+func TestGetQuotesRange(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().Truncate(time.Second)
+	quotes := []finance.Quote{
+		{Price: dec("123.45"), Symbol: "fb", Time: now},
+		{Price: dec("123.42"), Symbol: "fb", Time: now.Add(time.Minute)},
+		{Price: dec("123.40"), Symbol: "fb", Time: now.Add(2 * time.Minute)},
+		{Price: dec("123.38"), Symbol: "fb", Time: now.Add(3 * time.Minute)},
+		{Price: dec("234.56"), Symbol: "goog", Time: now.Add(time.Minute)},
+		{Price: dec("234.51"), Symbol: "goog", Time: now.Add(2 * time.Minute)},
+	}
+
+	testCases := []struct {
+		r        history.Range
+		expected []finance.Decimal
+		err      error
+	}{
+		{ // bounded window, newest first
+			r: history.Range{
+				From: now.Add(time.Minute),
+				To:   now.Add(3 * time.Minute),
+			},
+			expected: []finance.Decimal{dec("123.40"), dec("123.42")},
+		},
+		{ // open-ended window, oldest first, limited
+			r: history.Range{
+				From:  now.Add(time.Minute),
+				Limit: 2,
+				Order: history.Ascending,
+			},
+			expected: []finance.Decimal{dec("123.42"), dec("123.40")},
+		},
+		{ // sub-second bounds
+			r: history.Range{
+				From: now.Add(time.Minute - time.Millisecond),
+				To:   now.Add(time.Minute + time.Millisecond),
+			},
+			expected: []finance.Decimal{dec("123.42")},
+		},
+		{ // nothing in range
+			r:   history.Range{To: now},
+			err: history.ErrNotFound,
+		},
+		{ // inverted range
+			r:   history.Range{From: now, To: now.Add(-time.Minute)},
+			err: history.ErrInvalidRange,
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "stonks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Logf("removing temp dir: %v", err)
+		}
+	}()
+
+	c, err := New(DatabaseFile(filepath.Join(dir, DefaultDatabaseFile)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = c.Close() }()
+
+	err = c.SetQuotes(context.Background(), quotes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tc := range testCases {
+		actual, err := c.GetQuotesRange(context.Background(), "fb", tc.r)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%d: actual error: %v; expected: %v", i, err, tc.err)
+			continue
+		}
+
+		if len(actual) != len(tc.expected) {
+			t.Errorf("%d: actual quote count not equal to expected count", i)
+			t.Logf("expected: %#v", tc.expected)
+			t.Logf("actual:   %#v", actual)
+			continue
+		}
+
+		for j, q := range actual {
+			if q.Price != tc.expected[j] {
+				t.Errorf("%d.%d: actual price: %s; expected: %s", i, j,
+					q.Price, tc.expected[j])
+			}
+		}
+	}
+
+	batch, err := c.GetQuotesBatchRange(context.Background(),
+		[]string{"fb", "goog"}, history.Range{From: now.Add(2 * time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]finance.Decimal{
+		"fb":   {dec("123.38"), dec("123.40")},
+		"goog": {dec("234.51")},
+	}
+	if len(batch) != len(expected) {
+		t.Fatalf("actual batch: %#v; expected: %#v", batch, expected)
+	}
+	for symbol, prices := range expected {
+		if len(batch[symbol]) != len(prices) {
+			t.Errorf("%s: actual quotes: %#v; expected: %v", symbol,
+				batch[symbol], prices)
+			continue
+		}
+		for j, q := range batch[symbol] {
+			if q.Price != prices[j] {
+				t.Errorf("%s.%d: actual price: %s; expected: %s", symbol,
+					j, q.Price, prices[j])
+			}
+		}
+	}
+}
+
+func TestGetCandles(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2021, 5, 7, 14, 0, 0, 0, time.UTC)
+	quotes := []finance.Quote{
+		{Price: dec("100"), Symbol: "fb", Time: start.Add(10 * time.Second)},
+		{Price: dec("105"), Symbol: "fb", Time: start.Add(time.Minute)},
+		{Price: dec("95"), Symbol: "fb", Time: start.Add(3 * time.Minute)},
+		{Price: dec("102"), Symbol: "fb",
+			Time: start.Add(5*time.Minute - time.Millisecond)},
+		{Price: dec("110"), Symbol: "fb", Time: start.Add(5 * time.Minute)},
+	}
+
+	testCases := []struct {
+		interval time.Duration
+		r        history.Range
+		expected []history.Candle
+		err      error
+	}{
+		{
+			interval: 5 * time.Minute,
+			expected: []history.Candle{
+				{Symbol: "fb", Time: start.Add(5 * time.Minute), Open: dec("110"),
+					High: dec("110"), Low: dec("110"), Close: dec("110"), Count: 1},
+				{Symbol: "fb", Time: start, Open: dec("100"),
+					High: dec("105"), Low: dec("95"), Close: dec("102"),
+					Count: 4},
+			},
+		},
+		{
+			interval: 2 * time.Minute,
+			r: history.Range{
+				From:  start.Add(time.Minute),
+				Limit: 2,
+				Order: history.Ascending,
+			},
+			expected: []history.Candle{
+				{Symbol: "fb", Time: start, Open: dec("105"),
+					High: dec("105"), Low: dec("105"), Close: dec("105"),
+					Count: 1},
+				{Symbol: "fb", Time: start.Add(2 * time.Minute), Open: dec("95"),
+					High: dec("95"), Low: dec("95"), Close: dec("95"), Count: 1},
+			},
+		},
+		{
+			interval: time.Millisecond,
+			err:      history.ErrInvalidInterval,
+		},
+		{
+			interval: time.Minute,
+			r:        history.Range{To: start},
+			err:      history.ErrNotFound,
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "stonks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Logf("removing temp dir: %v", err)
+		}
+	}()
+
+	c, err := New(DatabaseFile(filepath.Join(dir, DefaultDatabaseFile)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = c.Close() }()
+
+	err = c.SetQuotes(context.Background(), quotes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tc := range testCases {
+		actual, err := c.GetCandles(context.Background(), "fb", tc.interval, tc.r)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%d: actual error: %v; expected: %v", i, err, tc.err)
+			continue
+		}
+
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("%d: actual candles not equal to expected", i)
+			t.Logf("expected: %#v", tc.expected)
+			t.Logf("actual:   %#v", actual)
+		}
+	}
+}
+
+func TestQuoteDetails(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "stonks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Logf("removing temp dir: %v", err)
+		}
+	}()
+
+	c, err := New(DatabaseFile(filepath.Join(dir, DefaultDatabaseFile)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = c.Close() }()
+
+	decimal := func(s string) *finance.Decimal {
+		d := dec(s)
+		return &d
+	}
+	float := func(f float64) *float64 { return &f }
+	integer := func(i int64) *int64 { return &i }
+
+	now := time.Date(2021, 4, 29, 20, 0, 0, 376000000, time.UTC)
+	expected := []finance.Quote{
+		{ // the market is open, so some statistics are unknown
+			Price: dec("328.71"), Symbol: "fb", Time: now.Add(time.Minute),
+			Provider: "iexcloud", Source: "IEX real time price",
+			CalculationPrice: "tops", PreviousClose: decimal("329.51"),
+			Change: decimal("-0.8"), ChangePercent: float(-0.00243),
+			MarketCap: integer(938138382075), PERatio: float(32.58),
+			Week52High: decimal("331.81"), Week52Low: decimal("198.76"),
+		},
+		{
+			Price: dec("329.51"), Symbol: "fb", Time: now, Provider: "iexcloud",
+			Source: "Close", CalculationPrice: "close",
+			Open: decimal("330.1"), High: decimal("331.81"), Low: decimal("321.61"),
+			Close: decimal("329.51"), Volume: integer(56526771),
+			PreviousClose: decimal("307.1"), Change: decimal("22.41"),
+			ChangePercent: float(0.07297), MarketCap: integer(940421582177),
+			PERatio: float(32.66), Week52High: decimal("331.81"),
+			Week52Low: decimal("198.76"),
+		},
+	}
+
+	err = c.SetQuotes(context.Background(),
+		[]finance.Quote{expected[1], expected[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := c.GetQuotes(context.Background(), "fb", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Error("actual quotes not equal to expected")
+		t.Logf("expected: %#v", expected)
+		t.Logf("actual:   %#v", actual)
+	}
+}
+
+func TestSetQuotesDuplicates(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "stonks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Logf("removing temp dir: %v", err)
+		}
+	}()
+
+	c, err := New(DatabaseFile(filepath.Join(dir, DefaultDatabaseFile)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = c.Close() }()
+
+	var (
+		ctx = context.Background()
+		now = time.Now()
+		est = now.In(time.FixedZone("EST", -5*60*60))
+	)
+	for _, quotes := range [][]finance.Quote{
+		{
+			{Price: dec("123.45"), Symbol: "fb", Time: now},
+			{Price: dec("234.56"), Symbol: "goog", Time: now},
+		},
+		{ // the same source timestamps, even in another time zone
+			{Price: dec("123.45"), Symbol: "FB", Time: est},
+			{Price: dec("234.56"), Symbol: "goog", Time: now},
+			{Price: dec("123.42"), Symbol: "fb", Time: now.Add(time.Second)},
+		},
+	} {
+		if err = c.SetQuotes(ctx, quotes); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	batch, err := c.GetQuotesBatch(ctx, []string{"fb", "goog"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := len(batch["fb"]); actual != 2 {
+		t.Errorf("actual fb quotes: %d; expected: 2", actual)
+	}
+	if actual := len(batch["goog"]); actual != 1 {
+		t.Errorf("actual goog quotes: %d; expected: 1", actual)
+	}
+}
+
+func TestSymbolRegistry(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "stonks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Logf("removing temp dir: %v", err)
+		}
+	}()
+
+	file := filepath.Join(dir, DefaultDatabaseFile)
+	c, err := New(DatabaseFile(file), Symbols([]string{"GOOG", "fb"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	symbols, err := c.Symbols(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"fb", "goog"}; !reflect.DeepEqual(symbols, expected) {
+		t.Errorf("actual seeded symbols: %v; expected: %v", symbols, expected)
+	}
+
+	for _, symbol := range []string{"NFLX", "nflx"} {
+		if err = c.AddSymbol(ctx, symbol); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = c.RemoveSymbol(ctx, "FB"); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.RemoveSymbol(ctx, "fb"); !errors.Is(err, history.ErrNotFound) {
+		t.Errorf("expected ErrNotFound; actual: %v", err)
+	}
+	_ = c.Close()
+
+	// The registered symbols persist and take precedence over the configured
+	// ones.
+	c, err = New(DatabaseFile(file), Symbols([]string{"aapl"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = c.Close() }()
+
+	symbols, err = c.Symbols(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"goog", "nflx"}; !reflect.DeepEqual(symbols, expected) {
+		t.Errorf("actual symbols after restart: %v; expected: %v", symbols, expected)
+	}
+}
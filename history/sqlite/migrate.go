@@ -0,0 +1,237 @@
+package sqlite
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	createSchemaVersionTable = `
+CREATE TABLE IF NOT EXISTS "schema_version"
+(
+	version integer not null
+		constraint schema_version_pk
+			primary key,
+	name text not null,
+	applied_at timestamp not null
+)`
+
+	insertSchemaVersion = `
+INSERT INTO schema_version (version, name, applied_at)
+  VALUES (?, ?, ?)`
+
+	selectSchemaVersions = `
+SELECT version, applied_at
+  FROM schema_version
+  ORDER BY version`
+
+	selectSchemaVersionTable = `
+SELECT COUNT(*)
+  FROM sqlite_master
+  WHERE type = 'table'
+    AND name = 'schema_version'`
+)
+
+// migrationFiles holds the up-migrations applied to each database, in order.
+// Each file is named NNNN_description.sql, where NNNN is the schema version
+// the migration upgrades the database to. Migrations are never edited once
+// released; changes to the schema go in a new file.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration is a versioned change to the database schema.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// MigrationStatus describes whether a migration has been applied to the
+// database. AppliedAt is zero for pending migrations.
+type MigrationStatus struct {
+	Migration
+	AppliedAt time.Time
+}
+
+// Pending returns true if the migration has not been applied.
+func (m MigrationStatus) Pending() bool {
+	return m.AppliedAt.IsZero()
+}
+
+// Migrations returns the embedded migrations ordered by version.
+func Migrations() ([]Migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations: %w", err)
+	}
+
+	migrations := make([]Migration, 0, len(entries))
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".sql")
+		parts := strings.SplitN(name, "_", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("migration %q: malformed name", entry.Name())
+		}
+
+		version, err := strconv.Atoi(parts[0])
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("migration %q: malformed version",
+				entry.Name())
+		}
+
+		b, err := migrationFiles.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading migration %q: %w", entry.Name(), err)
+		}
+
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    parts[1],
+			SQL:     string(b),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration %04d_%s: expected version %d",
+				m.Version, m.Name, i+1)
+		}
+	}
+
+	return migrations, nil
+}
+
+// Migrate applies any pending migrations to the database, in order, and
+// returns the migrations it applied. Each migration runs in its own
+// transaction alongside the update to the schema_version table, so a failed
+// migration leaves the database at the previous version.
+func (c *Client) Migrate(ctx context.Context) ([]Migration, error) {
+	_, err := c.db.ExecContext(ctx, createSchemaVersionTable)
+	if err != nil {
+		return nil, fmt.Errorf("creating schema_version table: %w", err)
+	}
+
+	status, err := c.MigrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, s := range status {
+		if !s.Pending() {
+			continue
+		}
+
+		err = c.applyMigration(ctx, s.Migration)
+		if err != nil {
+			return applied, err
+		}
+
+		applied = append(applied, s.Migration)
+	}
+
+	return applied, nil
+}
+
+// MigrationStatus returns every known migration and when, if ever, it was
+// applied to the database. It doesn't write to the database: if the database
+// has no schema_version table, no migrations have been applied.
+func (c *Client) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, 0, len(migrations))
+
+	var tables int
+	err = c.db.QueryRowContext(ctx, selectSchemaVersionTable).Scan(&tables)
+	if err != nil {
+		return nil, fmt.Errorf("selecting schema_version table: %w", err)
+	}
+	if tables == 0 {
+		for _, m := range migrations {
+			status = append(status, MigrationStatus{Migration: m})
+		}
+
+		return status, nil
+	}
+
+	rows, err := c.db.QueryContext(ctx, selectSchemaVersions)
+	if err != nil {
+		return nil, fmt.Errorf("selecting schema versions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var (
+			version int
+			t       time.Time
+		)
+		err = rows.Scan(&version, &t)
+		if err != nil {
+			return nil, fmt.Errorf("row scan: %w", err)
+		}
+
+		if version > len(migrations) {
+			return nil, fmt.Errorf("database schema version %d is newer than "+
+				"the latest known version %d", version, len(migrations))
+		}
+		applied[version] = t.UTC()
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	for _, m := range migrations {
+		status = append(status, MigrationStatus{
+			Migration: m,
+			AppliedAt: applied[m.Version],
+		})
+	}
+
+	return status, nil
+}
+
+// applyMigration runs the migration and records its version.
+func (c *Client) applyMigration(ctx context.Context, m Migration) error {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(ctx, m.SQL)
+	if err != nil {
+		return fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+	}
+
+	_, err = tx.ExecContext(ctx, insertSchemaVersion, m.Version, m.Name,
+		time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("recording migration %04d_%s: %w", m.Version,
+			m.Name, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("committing migration %04d_%s: %w", m.Version,
+			m.Name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+)
+
+func TestMigrationsPersistAcrossRestarts(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "stonks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Logf("removing temp dir: %v", err)
+		}
+	}()
+
+	file := filepath.Join(dir, DefaultDatabaseFile)
+	c, err := New(DatabaseFile(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.SetQuotes(context.Background(), []finance.Quote{
+		{Price: dec("123.45"), Symbol: "fb", Time: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = c.Close()
+
+	c, err = New(DatabaseFile(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = c.Close() }()
+
+	quotes, err := c.GetQuotes(context.Background(), "fb", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 1 || quotes[0].Price != dec("123.45") {
+		t.Errorf("quotes did not survive a restart: %#v", quotes)
+	}
+
+	status, err := c.MigrationStatus(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range status {
+		if s.Pending() {
+			t.Errorf("migration %04d_%s is pending", s.Version, s.Name)
+		}
+	}
+
+	applied, err := c.Migrate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("re-applied migrations: %#v", applied)
+	}
+
+	var index string
+	err = c.db.QueryRow(`SELECT name FROM sqlite_master
+		WHERE type = 'index' AND tbl_name = 'quotes'
+		  AND name = 'quotes_symbol_datetime_idx'`).Scan(&index)
+	if err != nil {
+		t.Errorf("quotes (symbol, datetime) index: %v", err)
+	}
+}
+
+func TestMigrateLegacyDatabase(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "stonks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Logf("removing temp dir: %v", err)
+		}
+	}()
+
+	// Create a database the way this package did before it supported
+	// migrations.
+	file := filepath.Join(dir, DefaultDatabaseFile)
+	db, err := sql.Open("sqlite3", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+CREATE TABLE "quotes"
+(
+	id integer not null
+		constraint quotes_pk
+			primary key autoincrement,
+	symbol text not null,
+	price real not null,
+	datetime timestamp not null
+)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Back then, polling a closed market stored the same quote repeatedly.
+	now := time.Now().UTC()
+	for i := 0; i < 2; i++ {
+		_, err = db.Exec(`INSERT INTO quotes (symbol, price, datetime)
+		VALUES (?, ?, ?)`, "goog", 234.56, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = db.Close()
+
+	c, err := New(DatabaseFile(file), AutoMigrate(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = c.Close() }()
+
+	status, err := c.MigrationStatus(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range status {
+		if !s.Pending() {
+			t.Errorf("migration %04d_%s is not pending", s.Version, s.Name)
+		}
+	}
+
+	// Checking the status leaves the database as it was.
+	var tables int
+	err = c.db.QueryRow(selectSchemaVersionTable).Scan(&tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tables != 0 {
+		t.Error("checking the migration status created the schema_version table")
+	}
+
+	applied, err := c.Migrate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(status) {
+		t.Errorf("applied %d migrations; expected %d", len(applied),
+			len(status))
+	}
+
+	quotes, err := c.GetQuotes(context.Background(), "goog", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 1 || quotes[0].Price != dec("234.56") {
+		t.Errorf("legacy quotes were not deduplicated by migration: %#v", quotes)
+	}
+}
@@ -0,0 +1,11 @@
+-- TODO: I can make an argument for and against normalizing the symbols
+-- column. I'll keep it as-is for the purposes of this demo.
+CREATE TABLE IF NOT EXISTS "quotes"
+(
+	id integer not null
+		constraint quotes_pk
+			primary key autoincrement,
+	symbol text not null,
+	price real not null,
+	datetime timestamp not null
+);
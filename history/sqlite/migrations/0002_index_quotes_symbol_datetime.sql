@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS quotes_symbol_datetime_idx
+	ON quotes (symbol, datetime);
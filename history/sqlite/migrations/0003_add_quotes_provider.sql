@@ -0,0 +1 @@
+ALTER TABLE quotes ADD COLUMN provider text not null default '';
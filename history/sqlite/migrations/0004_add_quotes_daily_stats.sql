@@ -0,0 +1,12 @@
+ALTER TABLE quotes ADD COLUMN open real;
+ALTER TABLE quotes ADD COLUMN high real;
+ALTER TABLE quotes ADD COLUMN low real;
+ALTER TABLE quotes ADD COLUMN close real;
+ALTER TABLE quotes ADD COLUMN volume integer;
+ALTER TABLE quotes ADD COLUMN previous_close real;
+ALTER TABLE quotes ADD COLUMN change real;
+ALTER TABLE quotes ADD COLUMN change_percent real;
+ALTER TABLE quotes ADD COLUMN market_cap integer;
+ALTER TABLE quotes ADD COLUMN pe_ratio real;
+ALTER TABLE quotes ADD COLUMN week52_high real;
+ALTER TABLE quotes ADD COLUMN week52_low real;
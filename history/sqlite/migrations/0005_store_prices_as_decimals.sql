@@ -0,0 +1,46 @@
+-- Prices become integer millionths (finance.Decimal) instead of floating
+-- point. SQLite can't change a column's type in place, so rebuild the table.
+CREATE TABLE "quotes_decimal"
+(
+	id integer not null
+		constraint quotes_pk
+			primary key autoincrement,
+	symbol text not null,
+	price integer not null,
+	datetime timestamp not null,
+	provider text not null default '',
+	open integer,
+	high integer,
+	low integer,
+	close integer,
+	volume integer,
+	previous_close integer,
+	change integer,
+	change_percent real,
+	market_cap integer,
+	pe_ratio real,
+	week52_high integer,
+	week52_low integer
+);
+
+INSERT INTO quotes_decimal
+SELECT id, symbol,
+	CAST(ROUND(price * 1000000) AS INTEGER),
+	datetime, provider,
+	CAST(ROUND(open * 1000000) AS INTEGER),
+	CAST(ROUND(high * 1000000) AS INTEGER),
+	CAST(ROUND(low * 1000000) AS INTEGER),
+	CAST(ROUND(close * 1000000) AS INTEGER),
+	volume,
+	CAST(ROUND(previous_close * 1000000) AS INTEGER),
+	CAST(ROUND(change * 1000000) AS INTEGER),
+	change_percent, market_cap, pe_ratio,
+	CAST(ROUND(week52_high * 1000000) AS INTEGER),
+	CAST(ROUND(week52_low * 1000000) AS INTEGER)
+FROM quotes;
+
+DROP TABLE quotes;
+ALTER TABLE quotes_decimal RENAME TO quotes;
+
+CREATE INDEX quotes_symbol_datetime_idx
+	ON quotes (symbol, datetime);
@@ -0,0 +1,2 @@
+ALTER TABLE quotes ADD COLUMN source text not null default '';
+ALTER TABLE quotes ADD COLUMN calculation_price text not null default '';
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS "symbols"
+(
+	symbol text not null
+		constraint symbols_pk
+			primary key,
+	added_at timestamp not null
+);
@@ -0,0 +1,14 @@
+-- A provider may report the same quote on every poll while the market is
+-- closed. Drop the duplicates already stored, keeping the first of each, so
+-- the index can enforce uniqueness from here on.
+DELETE FROM quotes
+WHERE id NOT IN (
+	SELECT MIN(id)
+	FROM quotes
+	GROUP BY symbol, datetime
+);
+
+DROP INDEX IF EXISTS quotes_symbol_datetime_idx;
+
+CREATE UNIQUE INDEX quotes_symbol_datetime_idx
+	ON quotes (symbol, datetime);
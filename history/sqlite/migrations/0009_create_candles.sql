@@ -0,0 +1,16 @@
+-- Retention tiers roll old quotes up into candles. Interval is the candle's
+-- length in seconds, and bucket is its start in Unix seconds, aligned to a
+-- multiple of the interval.
+CREATE TABLE IF NOT EXISTS "candles"
+(
+	symbol text not null,
+	interval integer not null,
+	bucket integer not null,
+	open integer not null,
+	high integer not null,
+	low integer not null,
+	close integer not null,
+	count integer not null,
+	constraint candles_pk
+		primary key (symbol, interval, bucket)
+);
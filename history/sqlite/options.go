@@ -1,9 +1,31 @@
 package sqlite
 
-import "time"
+import (
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
 
 type Option func(*Client)
 
+// AutoMigrate determines whether New applies pending schema migrations to the
+// database. Disable it to inspect or migrate the database explicitly.
+func AutoMigrate(enabled bool) Option {
+	return func(c *Client) {
+		c.autoMigrate = enabled
+	}
+}
+
+// CompactionInterval sets the duration between compactions, which roll old
+// quotes up into the retention tiers and delete them. An interval of zero or
+// less disables background compaction, leaving it to calls to Compact.
+func CompactionInterval(d time.Duration) Option {
+	return func(c *Client) {
+		c.compactInterval = d
+	}
+}
+
 // ConnMaxLifetime sets the maximum lifetime of each connection to the given
 // duration.
 func ConnMaxLifetime(d time.Duration) Option {
@@ -21,6 +43,15 @@ func DatabaseFile(f string) Option {
 	}
 }
 
+// Logger sets the logger that reports background compaction failures.
+func Logger(log *zap.SugaredLogger) Option {
+	return func(c *Client) {
+		if log != nil {
+			c.log = log
+		}
+	}
+}
+
 // MaxIdleConnections sets the maximum number of connections allowed to remain
 // idle.
 func MaxIdleConnections(i int) Option {
@@ -29,13 +60,30 @@ func MaxIdleConnections(i int) Option {
 	}
 }
 
-// Symbols configures the Archiver to track specific stock symbols.
+// Retention sets how long the client keeps raw quotes, and the tiers of
+// candles it rolls them up into before deleting them, finest first. Each
+// tier's interval must be a multiple of the previous tier's, and each tier
+// but the last must be kept at least as long as the next tier's interval. A
+// retention of zero or less keeps quotes forever, ignoring the tiers.
+func Retention(raw time.Duration, tiers ...Tier) Option {
+	t := make([]Tier, len(tiers))
+	copy(t, tiers)
+
+	return func(c *Client) {
+		c.retention = raw
+		c.tiers = t
+	}
+}
+
+// Symbols configures the Archiver to track specific stock symbols. They're
+// registered only if the database has no registered symbols, such as when it
+// is first created.
 func Symbols(symbols []string) Option {
 	return func(c *Client) {
 		c.symbols = make(map[string]struct{})
 
 		for _, symbol := range symbols {
-			c.symbols[symbol] = struct{}{}
+			c.symbols[strings.ToLower(symbol)] = struct{}{}
 		}
 	}
 }
@@ -0,0 +1,56 @@
+package sqlite
+
+import (
+	"strings"
+
+	"github.com/awoodbeck/faang-stonks/history"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+// DefaultTiers are the default retention tiers: 5-minute candles kept for 90
+// days and daily candles kept forever. They apply only if raw quotes have a
+// retention.
+var DefaultTiers = []string{"5m:2160h", "24h"}
+
+func init() {
+	history.Register("sqlite", history.Backend{
+		Flags: func(fs *pflag.FlagSet) {
+			fs.Duration("sqlite-compaction-interval", DefaultCompactionInterval, "duration between compactions")
+			fs.Duration("sqlite-conn-max-lifetime", DefaultConnsMaxLifetime, "max client connection lifetime")
+			fs.StringP("sqlite-database", "d", DefaultDatabaseFile, "database file path")
+			fs.Int("sqlite-max-idle-conn", DefaultMaxIdleConns, "max idle client connections")
+			fs.Duration("sqlite-retention", 0, "duration to keep raw quotes (0 = forever)")
+			fs.StringSlice("sqlite-retention-tiers", DefaultTiers, "candle interval:retention tiers to keep after raw quotes expire")
+		},
+		New: func(cfg history.Config, log *zap.SugaredLogger) (history.Storage, error) {
+			var tiers []Tier
+
+			// Environment variables arrive as a single comma-separated value.
+			specs := strings.Join(cfg.GetStringSlice("sqlite-retention-tiers"), ",")
+
+			for _, s := range strings.Split(specs, ",") {
+				s = strings.TrimSpace(s)
+				if s == "" {
+					continue
+				}
+
+				t, err := ParseTier(s)
+				if err != nil {
+					return nil, err
+				}
+				tiers = append(tiers, t)
+			}
+
+			return New(
+				CompactionInterval(cfg.GetDuration("sqlite-compaction-interval")),
+				ConnMaxLifetime(cfg.GetDuration("sqlite-conn-max-lifetime")),
+				DatabaseFile(cfg.GetString("sqlite-database")),
+				Logger(log),
+				MaxIdleConnections(cfg.GetInt("sqlite-max-idle-conn")),
+				Retention(cfg.GetDuration("sqlite-retention"), tiers...),
+				Symbols(cfg.GetStringSlice("symbols")),
+			)
+		},
+	})
+}
@@ -0,0 +1,448 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/awoodbeck/faang-stonks/history"
+	"github.com/awoodbeck/faang-stonks/metrics"
+)
+
+// DefaultCompactionInterval is the default duration between compactions.
+const DefaultCompactionInterval = time.Hour
+
+const (
+	// roll raw quotes up into candles, taking the first and last price in
+	// each bucket as the open and close, respectively; CONFLICT is either
+	// REPLACE or IGNORE
+	rollupQuotes = `
+WITH bucketed AS (
+  SELECT symbol, id, price, datetime,
+    CAST(strftime('%s', datetime) AS INTEGER) / ? * ? AS rollup
+  FROM quotes
+  WHERE datetime >= ?
+    AND datetime < ?
+), windowed AS (
+  SELECT symbol, rollup, price,
+    FIRST_VALUE(price) OVER w AS open,
+    LAST_VALUE(price) OVER w AS close
+  FROM bucketed
+  WINDOW w AS (PARTITION BY symbol, rollup ORDER BY datetime, id
+    ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING)
+)
+INSERT OR CONFLICT INTO candles
+  (symbol, interval, bucket, open, high, low, close, count)
+SELECT symbol, ?, rollup, open, MAX(price), MIN(price), close, COUNT(*)
+FROM windowed
+GROUP BY symbol, rollup`
+
+	// roll a finer tier's candles up into a coarser tier's candles, resolving
+	// conflicts like rollupQuotes
+	rollupCandles = `
+WITH bucketed AS (
+  SELECT symbol, bucket, open, high, low, close, count,
+    bucket / ? * ? AS rollup
+  FROM candles
+  WHERE interval = ?
+    AND bucket >= ?
+    AND bucket < ?
+), windowed AS (
+  SELECT symbol, rollup, high, low, count,
+    FIRST_VALUE(open) OVER w AS open,
+    LAST_VALUE(close) OVER w AS close
+  FROM bucketed
+  WINDOW w AS (PARTITION BY symbol, rollup ORDER BY bucket
+    ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING)
+)
+INSERT OR CONFLICT INTO candles
+  (symbol, interval, bucket, open, high, low, close, count)
+SELECT symbol, ?, rollup, open, MAX(high), MIN(low), close, SUM(count)
+FROM windowed
+GROUP BY symbol, rollup`
+
+	// same as rollupCandles, but for a single symbol and returned in the
+	// requested order
+	selectTierCandles = `
+WITH bucketed AS (
+  SELECT bucket, open, high, low, close, count,
+    bucket / ? * ? AS rollup
+  FROM candles
+  WHERE symbol = ?
+    AND interval = ?RANGE
+), windowed AS (
+  SELECT rollup, high, low, count,
+    FIRST_VALUE(open) OVER w AS open,
+    LAST_VALUE(close) OVER w AS close
+  FROM bucketed
+  WINDOW w AS (PARTITION BY rollup ORDER BY bucket
+    ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING)
+)
+SELECT rollup, open, MAX(high), MIN(low), close, SUM(count)
+FROM windowed
+GROUP BY rollup
+ORDER BY rollup DIR
+LIMIT ?`
+
+	deleteQuotesBefore = `
+DELETE FROM quotes
+  WHERE datetime < ?`
+
+	deleteCandlesBefore = `
+DELETE FROM candles
+  WHERE interval = ?
+    AND bucket < ?`
+)
+
+var ErrInvalidTier = fmt.Errorf("invalid retention tier")
+
+// Tier describes a retention tier: candles of the given interval kept for the
+// given duration. A retention of zero or less keeps the candles forever.
+type Tier struct {
+	Interval  time.Duration
+	Retention time.Duration
+}
+
+// String returns the tier in the form ParseTier accepts.
+func (t Tier) String() string {
+	return fmt.Sprintf("%s:%s", t.Interval, t.Retention)
+}
+
+// ParseTier parses a tier of the form "interval:retention" (e.g., "5m:2160h"
+// keeps 5-minute candles for 90 days). The retention may be omitted or zero
+// to keep the candles forever (e.g., "24h").
+func ParseTier(s string) (Tier, error) {
+	var (
+		t   Tier
+		err error
+	)
+
+	interval, retention := s, "0"
+	if i := strings.Index(s, ":"); i >= 0 {
+		interval, retention = s[:i], s[i+1:]
+	}
+
+	t.Interval, err = time.ParseDuration(interval)
+	if err != nil {
+		return t, fmt.Errorf("%w %q: %v", ErrInvalidTier, s, err)
+	}
+	t.Retention, err = time.ParseDuration(retention)
+	if err != nil {
+		return t, fmt.Errorf("%w %q: %v", ErrInvalidTier, s, err)
+	}
+
+	return t, nil
+}
+
+// validateTiers returns ErrInvalidTier unless each tier's interval is a whole
+// number of seconds and a multiple of the previous tier's interval, and the
+// raw quotes and every tier but the last are kept long enough to roll up into
+// the next tier.
+func validateTiers(retention time.Duration, tiers []Tier) error {
+	for i, t := range tiers {
+		if err := history.ValidateInterval(t.Interval); err != nil {
+			return fmt.Errorf("%w %s: %v", ErrInvalidTier, t, err)
+		}
+
+		source := retention
+		if i > 0 {
+			prev := tiers[i-1]
+			if t.Interval <= prev.Interval || t.Interval%prev.Interval != 0 {
+				return fmt.Errorf("%w %s: interval must be a multiple of %s",
+					ErrInvalidTier, t, prev.Interval)
+			}
+			source = prev.Retention
+		}
+		if source < t.Interval {
+			return fmt.Errorf("%w %s: the previous tier must be kept at "+
+				"least %s", ErrInvalidTier, t, t.Interval)
+		}
+	}
+
+	return nil
+}
+
+// Compact rolls quotes up into each retention tier's candles, then deletes
+// the quotes and candles older than their retention. Each tier rolls up the
+// complete buckets of the tier before it (or the raw quotes, for the first
+// tier). Buckets that are still fully retained are rolled up again each time,
+// so quotes archived late are accounted for until they age out. Older buckets
+// are rolled up only if their candles don't exist yet, as is the case the
+// first time a database is compacted, since their quotes may be partially
+// deleted. Compact is a no-op if the client keeps quotes forever.
+func (c Client) Compact(ctx context.Context) error {
+	if c.retention <= 0 {
+		return nil
+	}
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := c.now()
+	for i, t := range c.tiers {
+		seconds := int64(t.Interval / time.Second)
+
+		source := c.retention
+		if i > 0 {
+			source = c.tiers[i-1].Retention
+		}
+		to := alignDown(now, t.Interval)
+		from := alignUp(now.Add(-source), t.Interval)
+		if from.After(to) {
+			from = to
+		}
+
+		for _, w := range []struct {
+			conflict string
+			from, to time.Time
+		}{
+			{"IGNORE", time.Unix(0, 0), from},
+			{"REPLACE", from, to},
+		} {
+			if i == 0 {
+				_, err = tx.ExecContext(ctx,
+					strings.Replace(rollupQuotes, "CONFLICT", w.conflict, 1),
+					seconds, seconds, w.from.UTC(), w.to.UTC(), seconds)
+			} else {
+				_, err = tx.ExecContext(ctx,
+					strings.Replace(rollupCandles, "CONFLICT", w.conflict, 1),
+					seconds, seconds, int64(c.tiers[i-1].Interval/time.Second),
+					w.from.Unix(), w.to.Unix(), seconds)
+			}
+			if err != nil {
+				return fmt.Errorf("rolling up %s candles: %w", t.Interval, err)
+			}
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, deleteQuotesBefore,
+		now.Add(-c.retention).UTC())
+	if err != nil {
+		return fmt.Errorf("deleting quotes: %w", err)
+	}
+
+	for _, t := range c.tiers {
+		if t.Retention <= 0 {
+			continue
+		}
+
+		_, err = tx.ExecContext(ctx, deleteCandlesBefore,
+			int64(t.Interval/time.Second), now.Add(-t.Retention).Unix())
+		if err != nil {
+			return fmt.Errorf("deleting %s candles: %w", t.Interval, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// compactEvery compacts the database at startup and then at each interval
+// until the context is canceled, logging each failed compaction.
+func (c Client) compactEvery(ctx context.Context, interval time.Duration) {
+	defer close(c.compacted)
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		if err := c.Compact(ctx); err != nil && ctx.Err() == nil {
+			c.log.Errorf("compacting: %v", err)
+			metrics.CompactionFailures.Inc()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// candleSource is where GetCandles reads the candles that start at or after
+// from: the raw quotes if interval is zero, or the tier with the interval.
+type candleSource struct {
+	interval time.Duration
+	from     time.Time
+}
+
+// candleSources returns the sources GetCandles reads candles of the given
+// interval from, newest first. Each source covers the time from its start
+// until the start of the source before it. The raw quotes cover the time they
+// are kept, followed by each tier whose interval divides the candle interval
+// and whose candles are kept longer. The last source covers whatever older
+// quotes or candles remain.
+func (c Client) candleSources(interval time.Duration) []candleSource {
+	sources := []candleSource{{}}
+	if c.retention <= 0 {
+		return sources
+	}
+
+	now := c.now()
+	sources[0].from = alignUp(now.Add(-c.retention), interval)
+
+	for _, t := range c.tiers {
+		if interval%t.Interval != 0 {
+			continue
+		}
+
+		var from time.Time
+		if t.Retention > 0 {
+			from = alignUp(now.Add(-t.Retention), interval)
+		}
+
+		last := sources[len(sources)-1]
+		if !from.Before(last.from) {
+			continue
+		}
+		sources = append(sources, candleSource{interval: t.Interval, from: from})
+	}
+	sources[len(sources)-1].from = time.Time{}
+
+	return sources
+}
+
+// tiered returns true if the client rolls old quotes up into retention tiers.
+func (c Client) tiered() bool {
+	return c.retention > 0 && len(c.tiers) > 0
+}
+
+// quoteSources returns the sources GetQuotesRange reads quotes from, newest
+// first, in the form candleSources returns. The raw quotes cover the time
+// they are kept, followed by each tier whose candles are kept longer. Unlike
+// candle sources, they aren't aligned to an interval: each tier covers the
+// candles that start before the source after it, so the quotes deleted from
+// a partially retained candle are represented by that candle's close.
+func (c Client) quoteSources() []candleSource {
+	sources := []candleSource{{}}
+	if !c.tiered() {
+		return sources
+	}
+
+	now := c.now()
+	sources[0].from = now.Add(-c.retention)
+
+	for _, t := range c.tiers {
+		var from time.Time
+		if t.Retention > 0 {
+			from = now.Add(-t.Retention)
+		}
+
+		last := sources[len(sources)-1]
+		if !from.Before(last.from) {
+			continue
+		}
+		sources = append(sources, candleSource{interval: t.Interval, from: from})
+	}
+	sources[len(sources)-1].from = time.Time{}
+
+	return sources
+}
+
+// getTierQuotes returns a quote for each of the tier's candles that start
+// within the range, priced at the candle's close.
+func (c Client) getTierQuotes(ctx context.Context, symbol string,
+	tier time.Duration, r history.Range) ([]finance.Quote, error) {
+	candles, err := c.getTierCandles(ctx, symbol, tier, tier, r)
+	if err != nil {
+		return nil, err
+	}
+
+	quotes := make([]finance.Quote, len(candles))
+	for i, candle := range candles {
+		quotes[i] = finance.Quote{
+			Price:  candle.Close,
+			Symbol: candle.Symbol,
+			Time:   candle.Time,
+			Source: fmt.Sprintf("%s candle", tier),
+		}
+	}
+
+	return quotes, nil
+}
+
+// clip returns the part of the range that falls within a source covering the
+// time from its start until the start of the newer source before it, which is
+// zero for the newest source. It returns false if none of the range does.
+func clip(r history.Range, from, until time.Time) (history.Range, bool) {
+	if r.From.Before(from) {
+		r.From = from
+	}
+	if !until.IsZero() && (r.To.IsZero() || until.Before(r.To)) {
+		r.To = until
+	}
+
+	return r, r.To.IsZero() || r.From.Before(r.To)
+}
+
+// getTierCandles returns the tier's candles aggregated into candles of the
+// given interval that fall within the range.
+func (c Client) getTierCandles(ctx context.Context, symbol string,
+	tier, interval time.Duration, r history.Range) ([]history.Candle, error) {
+	var (
+		clause string
+		args   []interface{}
+	)
+	if !r.From.IsZero() {
+		clause += "\n    AND bucket >= ?"
+		args = append(args, r.From.Unix())
+	}
+	if !r.To.IsZero() {
+		clause += "\n    AND bucket < ?"
+		args = append(args, r.To.Unix())
+	}
+
+	seconds := int64(interval / time.Second)
+	args = append([]interface{}{seconds, seconds, symbol,
+		int64(tier / time.Second)}, args...)
+	args = append(args, limit(r))
+
+	return c.queryCandles(ctx, symbol, rangeQuery(selectTierCandles, clause, r),
+		args...)
+}
+
+// mergeCandles sorts the candles from each source into the range's order and
+// applies its limit.
+func mergeCandles(candles []history.Candle, r history.Range) []history.Candle {
+	sort.Slice(candles, func(i, j int) bool {
+		if r.Order == history.Ascending {
+			return candles[i].Time.Before(candles[j].Time)
+		}
+		return candles[i].Time.After(candles[j].Time)
+	})
+
+	if r.Limit > 0 && len(candles) > r.Limit {
+		candles = candles[:r.Limit]
+	}
+
+	return candles
+}
+
+// alignDown returns the latest time at or before t that's a multiple of the
+// interval since the Unix epoch, as candle buckets are.
+func alignDown(t time.Time, interval time.Duration) time.Time {
+	seconds := int64(interval / time.Second)
+
+	return time.Unix(t.Unix()/seconds*seconds, 0).UTC()
+}
+
+// alignUp returns the earliest time at or after t that's a multiple of the
+// interval since the Unix epoch.
+func alignUp(t time.Time, interval time.Duration) time.Time {
+	aligned := alignDown(t, interval)
+	if aligned.Before(t) {
+		aligned = aligned.Add(interval)
+	}
+
+	return aligned
+}
@@ -0,0 +1,368 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/awoodbeck/faang-stonks/history"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestParseTier(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		s        string
+		expected Tier
+		err      error
+	}{
+		{s: "5m:2160h", expected: Tier{5 * time.Minute, 2160 * time.Hour}},
+		{s: "24h", expected: Tier{24 * time.Hour, 0}},
+		{s: "1h:0", expected: Tier{time.Hour, 0}},
+		{s: "", err: ErrInvalidTier},
+		{s: "5m:forever", err: ErrInvalidTier},
+		{s: "daily:0", err: ErrInvalidTier},
+	}
+
+	for i, tc := range testCases {
+		actual, err := ParseTier(tc.s)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%d: actual error: %v; expected: %v", i, err, tc.err)
+			continue
+		}
+		if tc.err == nil && actual != tc.expected {
+			t.Errorf("%d: actual: %s; expected: %s", i, actual, tc.expected)
+		}
+	}
+}
+
+func TestValidateTiers(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		raw   time.Duration
+		tiers []Tier
+		valid bool
+	}{
+		{raw: time.Hour, valid: true},
+		{raw: time.Hour, tiers: []Tier{{5 * time.Minute, 0}}, valid: true},
+		{raw: time.Hour, tiers: []Tier{{5 * time.Minute, 24 * time.Hour},
+			{24 * time.Hour, 0}}, valid: true},
+		// not a whole number of seconds
+		{raw: time.Hour, tiers: []Tier{{1500 * time.Millisecond, 0}}},
+		// raw quotes expire before a bucket completes
+		{raw: time.Minute, tiers: []Tier{{5 * time.Minute, 0}}},
+		// not a multiple of the previous interval
+		{raw: time.Hour, tiers: []Tier{{5 * time.Minute, 24 * time.Hour},
+			{7 * time.Minute, 0}}},
+		// the previous tier is kept forever
+		{raw: time.Hour, tiers: []Tier{{5 * time.Minute, 0}, {time.Hour, 0}}},
+	}
+
+	for i, tc := range testCases {
+		err := validateTiers(tc.raw, tc.tiers)
+		if tc.valid && err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+		}
+		if !tc.valid && !errors.Is(err, ErrInvalidTier) {
+			t.Errorf("%d: expected ErrInvalidTier; actual: %v", i, err)
+		}
+	}
+}
+
+func TestCompact(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "stonks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Logf("removing temp dir: %v", err)
+		}
+	}()
+
+	file := filepath.Join(dir, DefaultDatabaseFile)
+	c, err := New(DatabaseFile(file),
+		Retention(2*time.Hour,
+			Tier{5 * time.Minute, 24 * time.Hour},
+			Tier{time.Hour, 0},
+		),
+		CompactionInterval(0),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = c.Close() }()
+
+	// A quote every minute for the 30 hours before now, with prices that
+	// wander up and down.
+	ctx := context.Background()
+	now := time.Date(2021, 5, 7, 12, 0, 30, 0, time.UTC)
+	hour := now.Truncate(time.Hour)
+	c.now = func() time.Time { return now }
+
+	var quotes []finance.Quote
+	for i := 30 * 60; i > 0; i-- {
+		quotes = append(quotes, finance.Quote{
+			Price:  finance.Decimal(100000000 + (i*7919)%5000*1000),
+			Symbol: "fb",
+			Time:   now.Add(-time.Duration(i) * time.Minute),
+		})
+	}
+	if err = c.SetQuotes(ctx, quotes); err != nil {
+		t.Fatal(err)
+	}
+
+	// A client that keeps quotes forever aggregates every candle from them.
+	plain, err := New(DatabaseFile(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = plain.Close() }()
+
+	candles := func(c *Client, interval time.Duration,
+		r history.Range) []history.Candle {
+		actual, err := c.GetCandles(ctx, "fb", interval, r)
+		if err != nil {
+			t.Fatalf("%s candles: %v", interval, err)
+		}
+		return actual
+	}
+	hourly := candles(plain, time.Hour, history.Range{})
+	daily := candles(plain, 24*time.Hour, history.Range{Order: history.Ascending})
+	recent := candles(plain, 5*time.Minute,
+		history.Range{From: hour.Add(-23 * time.Hour)})
+	old := candles(plain, 5*time.Minute, history.Range{
+		From:  hour.Add(-20 * time.Hour),
+		To:    hour.Add(-19 * time.Hour),
+		Order: history.Ascending,
+	})
+
+	// Compacting twice leaves the same result.
+	for i := 0; i < 2; i++ {
+		if err = c.Compact(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	raw, err := c.GetQuotesRange(ctx, "fb",
+		history.Range{From: now.Add(-2 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw) != 120 {
+		t.Errorf("actual raw quotes after compaction: %d; expected: 120", len(raw))
+	}
+
+	// Quotes older than the raw quotes come from the tiers, one per candle.
+	closes := func(candles []history.Candle, source string) []finance.Quote {
+		quotes := make([]finance.Quote, len(candles))
+		for i, c := range candles {
+			quotes[i] = finance.Quote{Price: c.Close, Symbol: "fb",
+				Time: c.Time, Source: source}
+		}
+		return quotes
+	}
+	tiered := func(r history.Range) []finance.Quote {
+		actual, err := c.GetQuotesRange(ctx, "fb", r)
+		if err != nil {
+			t.Fatalf("quotes: %v", err)
+		}
+		return actual
+	}
+	for _, tc := range []struct {
+		name             string
+		actual, expected []finance.Quote
+	}{
+		{"5-minute", tiered(history.Range{From: hour.Add(-20 * time.Hour),
+			To: hour.Add(-19 * time.Hour), Order: history.Ascending}),
+			closes(old, "5m0s candle")},
+		{"hourly", tiered(history.Range{From: hour.Add(-28 * time.Hour),
+			To: hour.Add(-26 * time.Hour)}), closes(hourly[26:28], "1h0m0s candle")},
+		{"oldest", tiered(history.Range{Order: history.Ascending, Limit: 1}),
+			closes(hourly[len(hourly)-1:], "1h0m0s candle")},
+		{"newest tier", tiered(history.Range{Limit: 122})[120:],
+			closes(candles(c, 5*time.Minute, history.Range{
+				To: now.Add(-2 * time.Hour), Limit: 2}), "5m0s candle")},
+	} {
+		if !reflect.DeepEqual(tc.actual, tc.expected) {
+			t.Errorf("%s quotes do not match the tier's candles", tc.name)
+			t.Logf("actual:   %#v", tc.actual)
+			t.Logf("expected: %#v", tc.expected)
+		}
+	}
+
+	batch, err := c.GetQuotesBatchRange(ctx, []string{"fb", "goog"},
+		history.Range{From: hour.Add(-28 * time.Hour), To: hour.Add(-26 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := finance.QuoteBatch{"fb": closes(hourly[26:28], "1h0m0s candle")}
+	if !reflect.DeepEqual(batch, expected) {
+		t.Errorf("actual batch: %#v", batch)
+	}
+	if last, err := c.GetQuotes(ctx, "fb", 121); err != nil || len(last) != 121 {
+		t.Errorf("actual last quotes: %d, %v; expected: 121", len(last), err)
+	}
+
+	// Candles read from the tiers match those aggregated from the raw
+	// quotes before compaction.
+	for _, tc := range []struct {
+		name             string
+		actual, expected []history.Candle
+	}{
+		{"hourly", candles(c, time.Hour, history.Range{}), hourly},
+		{"daily", candles(c, 24*time.Hour,
+			history.Range{Order: history.Ascending}), daily},
+		{"recent 5-minute", candles(c, 5*time.Minute,
+			history.Range{From: hour.Add(-23 * time.Hour)}), recent},
+		{"limited hourly", candles(c, time.Hour, history.Range{Limit: 5}),
+			hourly[:5]},
+		{"bounded hourly", candles(c, time.Hour, history.Range{
+			From: hour.Add(-10 * time.Hour), To: hour.Add(-time.Hour)}),
+			hourly[1:10]},
+	} {
+		if !reflect.DeepEqual(tc.actual, tc.expected) {
+			t.Errorf("%s candles do not match the raw quotes' candles", tc.name)
+			t.Logf("actual:   %#v", tc.actual)
+			t.Logf("expected: %#v", tc.expected)
+		}
+	}
+
+	// 5-minute candles older than their retention are gone.
+	old, err = c.GetCandles(ctx, "fb", 5*time.Minute,
+		history.Range{Order: history.Ascending, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oldest := now.Add(-24 * time.Hour); old[0].Time.Before(oldest) {
+		t.Errorf("oldest 5-minute candle at %s precedes %s", old[0].Time, oldest)
+	}
+
+	// Quotes archived late roll up on the next compaction, while they're
+	// still retained.
+	late := finance.Quote{Price: dec("999"), Symbol: "fb",
+		Time: hour.Add(-89 * time.Minute)}
+	if err = c.SetQuotes(ctx, []finance.Quote{late}); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(10 * time.Minute)
+	if err = c.Compact(ctx); err != nil {
+		t.Fatal(err)
+	}
+	actual, err := c.GetCandles(ctx, "fb", time.Hour, history.Range{
+		From: hour.Add(-2 * time.Hour), To: hour.Add(-time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actual) != 1 || actual[0].High != dec("999") || actual[0].Count != 61 {
+		t.Errorf("late quote not rolled up: %#v", actual)
+	}
+}
+
+func TestCompactInBackground(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "stonks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Logf("removing temp dir: %v", err)
+		}
+	}()
+
+	file := filepath.Join(dir, DefaultDatabaseFile)
+	c, err := New(DatabaseFile(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	err = c.SetQuotes(ctx, []finance.Quote{
+		{Price: dec("123.45"), Symbol: "fb", Time: time.Now().Add(-2 * time.Hour)},
+		{Price: dec("123.42"), Symbol: "fb", Time: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = c.Close()
+
+	// The client compacts as soon as it starts.
+	c, err = New(DatabaseFile(file), Retention(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		quotes, err := c.GetQuotesRange(ctx, "fb", history.Range{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(quotes) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expired quote not compacted: %#v", quotes)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err = c.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompactionFailureLogged(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "stonks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Logf("removing temp dir: %v", err)
+		}
+	}()
+
+	// Without its candles table, the database can't be compacted.
+	file := filepath.Join(dir, DefaultDatabaseFile)
+	c, err := New(DatabaseFile(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.db.Exec(`DROP TABLE candles`); err != nil {
+		t.Fatal(err)
+	}
+	_ = c.Close()
+
+	core, logs := observer.New(zapcore.ErrorLevel)
+	c, err = New(DatabaseFile(file),
+		Retention(time.Hour, Tier{5 * time.Minute, 0}),
+		CompactionInterval(10*time.Millisecond), Logger(zap.New(core).Sugar()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = c.Close() }()
+
+	deadline := time.Now().Add(time.Second)
+	for logs.FilterMessageSnippet("candles").Len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("compaction failure not logged: %v", logs.All())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,21 @@
+package history
+
+import "context"
+
+// SymbolRegistry describes an object that keeps the set of tracked stock
+// symbols, allowing the set to change while the service runs.
+type SymbolRegistry interface {
+	// AddSymbol accepts a context for cancellation support and a stock
+	// symbol, and adds the symbol to the tracked set. Adding a tracked symbol
+	// has no effect.
+	AddSymbol(ctx context.Context, symbol string) error
+
+	// RemoveSymbol accepts a context for cancellation support and a stock
+	// symbol, and removes the symbol from the tracked set. It returns
+	// ErrNotFound if the symbol isn't tracked.
+	RemoveSymbol(ctx context.Context, symbol string) error
+
+	// Symbols accepts a context for cancellation support and returns the
+	// tracked symbols in alphabetical order.
+	Symbols(ctx context.Context) ([]string, error)
+}
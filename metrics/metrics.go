@@ -12,10 +12,19 @@ func init() {
 		ClientInFlightRequests,
 		ClientRequestDuration,
 		ClientTLSDuration,
+		CompactionFailures,
+		DuplicateQuotes,
+		FanoutFailures,
+		ProviderCircuitState,
 		ServerAPIRequests,
 		ServerInFlightRequests,
 		ServerRequestDuration,
 		ServerResponseBytes,
+		SnapshotFailures,
+		SpoolOldestAge,
+		SpoolQuotes,
+		StreamDroppedQuotes,
+		StreamSubscribers,
 	)
 }
 
@@ -63,6 +72,48 @@ var ClientTLSDuration = prometheus.NewHistogramVec(
 	}, []string{},
 )
 
+// CompactionFailures counts the background compactions the SQLite storage
+// backend failed to complete.
+var CompactionFailures = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "sqlite_compaction_failures_total",
+		Help: "A counter of failed SQLite storage compactions.",
+	},
+)
+
+// DuplicateQuotes counts the quotes dropped because a quote with the same
+// symbol and source timestamp was already archived, labeled by the layer that
+// dropped them: the poller or a storage backend. A quote the poller drops
+// never reaches storage, but behind the fanout backend each target that drops
+// the same duplicate counts it under its own layer, so summing the layers may
+// count a duplicate more than once.
+var DuplicateQuotes = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "duplicate_quotes_total",
+		Help: "A counter of duplicate quotes dropped instead of archived.",
+	}, []string{"layer"},
+)
+
+// FanoutFailures counts the batches of quotes each of the fan-out storage
+// backend's targets failed to archive.
+var FanoutFailures = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "fanout_target_failures_total",
+		Help: "A counter of batches a fan-out target failed to archive.",
+	}, []string{"target", "policy"},
+)
+
+// ProviderCircuitState tracks the circuit breaker state of each finance
+// provider chained by a failover provider: 0 is closed, 1 is half-open, and
+// 2 is open.
+var ProviderCircuitState = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "provider_circuit_state",
+		Help: "A gauge of each finance provider's circuit breaker state.",
+	},
+	[]string{"provider"},
+)
+
 // ServerAPIRequests counts the number of API requests handled by the server.
 var ServerAPIRequests = prometheus.NewCounterVec(
 	prometheus.CounterOpts{
@@ -101,3 +152,46 @@ var ServerResponseBytes = prometheus.NewHistogramVec(
 	},
 	[]string{},
 )
+
+// SnapshotFailures counts the periodic snapshots the memory storage backend
+// failed to write.
+var SnapshotFailures = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "memory_snapshot_failures_total",
+		Help: "A counter of failed memory storage snapshots.",
+	},
+)
+
+// SpoolOldestAge tracks how long the oldest quotes in the poller's spool have
+// waited to be archived.
+var SpoolOldestAge = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "spool_oldest_age_seconds",
+		Help: "A gauge of the age of the oldest spooled quotes.",
+	},
+)
+
+// SpoolQuotes tracks the number of quotes in the poller's spool.
+var SpoolQuotes = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "spool_quotes",
+		Help: "A gauge of quotes spooled while the archiver fails.",
+	},
+)
+
+// StreamDroppedQuotes counts the quotes dropped from slow stream subscribers'
+// buffers.
+var StreamDroppedQuotes = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "stream_dropped_quotes_total",
+		Help: "A counter of quotes dropped because a stream subscriber fell behind.",
+	},
+)
+
+// StreamSubscribers tracks the number of active stream subscribers.
+var StreamSubscribers = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "stream_subscribers",
+		Help: "A gauge of active stream subscribers.",
+	},
+)
@@ -0,0 +1,54 @@
+package poll
+
+import (
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/calendar"
+	"github.com/awoodbeck/faang-stonks/history"
+	"github.com/awoodbeck/faang-stonks/spool"
+)
+
+type Option func(*Poller)
+
+// Notify publishes each batch of quotes to the given publisher after the
+// archiver successfully stores them, or the spool buffers them.
+func Notify(p Publisher) Option {
+	return func(poller *Poller) {
+		poller.publisher = p
+	}
+}
+
+// Registry polls the symbols registered with the given registry, re-reading
+// them before each poll so symbols can be added or removed while the poller
+// runs.
+func Registry(r history.SymbolRegistry) Option {
+	return func(poller *Poller) {
+		poller.registry = r
+	}
+}
+
+// Schedule polls at the given interval during each of the calendar's
+// sessions. Sessions without an interval use the interval passed to Poll. An
+// interval of zero skips the session entirely, such as to avoid polling while
+// the market is closed.
+func Schedule(c *calendar.Calendar,
+	intervals map[calendar.Session]time.Duration) Option {
+	i := make(map[calendar.Session]time.Duration, len(intervals))
+	for session, d := range intervals {
+		i[session] = d
+	}
+
+	return func(poller *Poller) {
+		poller.calendar = c
+		poller.intervals = i
+	}
+}
+
+// Spool buffers the quotes the archiver fails to store in the given spool,
+// and archives them, in order, before any newer quotes once the archiver
+// recovers.
+func Spool(s *spool.Spool) Option {
+	return func(poller *Poller) {
+		poller.spool = s
+	}
+}
@@ -5,11 +5,16 @@ package poll
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/awoodbeck/faang-stonks/calendar"
 	"github.com/awoodbeck/faang-stonks/finance"
 	"github.com/awoodbeck/faang-stonks/history"
+	"github.com/awoodbeck/faang-stonks/metrics"
+	"github.com/awoodbeck/faang-stonks/spool"
 	"go.uber.org/zap"
 )
 
@@ -28,13 +33,35 @@ type Poller struct {
 	log      *zap.SugaredLogger
 	archiver history.Archiver
 	provider finance.Provider
+
+	publisher Publisher
+	registry  history.SymbolRegistry
+	calendar  *calendar.Calendar
+	intervals map[calendar.Session]time.Duration
+	spool     *spool.Spool
+
+	// archived holds the timestamp of the last quote archived for each symbol.
+	archived map[string]time.Time
+}
+
+// Publisher describes an object that can publish newly archived quotes, such
+// as a pubsub.Hub.
+type Publisher interface {
+	Publish(quotes []finance.Quote)
 }
 
 // Poll accepts an interval and a slice of stock symbols, and polls their
-// current price at regular intervals, archiving the results.
+// current price at regular intervals, archiving the results. If the poller
+// has a symbol registry, it polls the registered symbols instead, reading
+// them before each poll, and falls back to the last symbols it read if the
+// registry fails. If the poller has a schedule, the interval applies to
+// sessions without one of their own. Quotes the provider reports unchanged
+// since the last poll (e.g., while the market is closed) are neither archived
+// nor published again. If the poller has a spool, quotes the archiver fails
+// to store are spooled until it recovers.
 func (p Poller) Poll(ctx context.Context, interval time.Duration,
 	symbols ...string) {
-	if len(symbols) == 0 {
+	if len(symbols) == 0 && p.registry == nil {
 		p.log.Warn("no symbols to poll")
 		return
 	}
@@ -44,36 +71,219 @@ func (p Poller) Poll(ctx context.Context, interval time.Duration,
 	}
 
 	p.log.Infof("polling interval: %s", interval)
-	t := time.NewTicker(interval)
-	defer t.Stop()
+	for _, session := range []calendar.Session{calendar.PreMarket,
+		calendar.Regular, calendar.AfterHours, calendar.Closed} {
+		if d, ok := p.intervals[session]; ok {
+			p.log.Infof("%s session polling interval: %s", session, d)
+		}
+	}
 
 	for {
+		start := time.Now()
+
+		if p.calendar != nil {
+			if session := p.calendar.Session(start); p.skipped(session) {
+				next, nextSession := p.calendar.Next(start)
+				p.log.Infof("skipping %s session; next poll in %s session at %s",
+					session, nextSession, next.Format(time.RFC3339))
+				if !sleep(ctx, next.Sub(start)) {
+					p.log.Debug("stopping poller")
+					return
+				}
+				continue
+			}
+		}
+
+		if p.registry != nil {
+			registered, err := p.registry.Symbols(ctx)
+			if err != nil {
+				p.log.Errorf("reading symbols: %v", err)
+			} else {
+				symbols = registered
+			}
+		}
+
+		if len(symbols) == 0 {
+			p.log.Warn("no symbols to poll")
+			if !sleep(ctx, time.Until(start.Add(p.interval(start, interval)))) {
+				p.log.Debug("stopping poller")
+				return
+			}
+			continue
+		}
+
 		quotes, err := p.provider.GetQuotes(ctx, symbols...)
+
+		// Archive partial results rather than nothing at all.
+		var symErrs finance.SymbolErrors
+		if err != nil && len(quotes) > 0 && errors.As(err, &symErrs) {
+			p.log.Warnf("polling provider: %v", err)
+			err = nil
+		}
+
+		// Spooled quotes go to the archiver before newer ones.
+		replayed := p.replay(ctx)
+
 		if err != nil {
 			p.log.Errorf("polling provider: %v", err)
+		} else if quotes = p.dedup(quotes); len(quotes) == 0 {
+			p.log.Debug("no new quotes")
 		} else {
 			p.log.Debugf("received: %#v", quotes)
-			err = p.archiver.SetQuotes(ctx, quotes)
-			if err != nil {
-				p.log.Errorf("updating history: %v", err)
-				continue
+			if p.archive(ctx, quotes, replayed) {
+				for _, q := range quotes {
+					p.archived[strings.ToLower(q.Symbol)] = q.Time
+				}
+
+				if p.publisher != nil {
+					p.publisher.Publish(quotes)
+				}
 			}
-			p.log.Debug("stored")
 		}
+		p.observeSpool()
 
-		select {
-		case <-ctx.Done():
+		if !sleep(ctx, time.Until(start.Add(p.interval(start, interval)))) {
 			p.log.Debug("stopping poller")
 			return
-		case <-t.C:
 		}
 	}
 }
 
-// New accepts a finance.Provider, a history.Archiver, and a logger, and
-// returns a pointer to a poller Client.
-func New(p finance.Provider, a history.Archiver, l *zap.SugaredLogger) (
-	*Poller, error) {
+// archive stores the quotes, returning true if the archiver stored them or
+// the spool buffered them. Unless the spool was replayed, the quotes go
+// straight to the spool so they aren't archived ahead of older quotes. Quotes
+// the archiver rejects for good aren't spooled, since retrying them would
+// fail, too.
+func (p Poller) archive(ctx context.Context, quotes []finance.Quote,
+	replayed bool) bool {
+	if replayed {
+		err := p.archiver.SetQuotes(ctx, quotes)
+		if err == nil {
+			p.log.Debug("stored")
+			return true
+		}
+		p.log.Errorf("updating history: %v", err)
+		if history.Rejected(err) {
+			return false
+		}
+	}
+
+	if p.spool == nil {
+		return false
+	}
+	if err := p.spool.Append(quotes); err != nil {
+		p.log.Errorf("spooling quotes: %v", err)
+		return false
+	}
+	p.log.Warnf("spooled %d quotes", len(quotes))
+
+	return true
+}
+
+// replay archives any spooled quotes, returning false if some remain spooled.
+func (p Poller) replay(ctx context.Context) bool {
+	if p.spool == nil || p.spool.Len() == 0 {
+		return true
+	}
+
+	dropped, err := p.spool.Replay(ctx, p.archiver)
+	if dropped > 0 {
+		p.log.Errorf("dropped %d spooled quotes the archiver rejected", dropped)
+	}
+	if err != nil {
+		p.log.Errorf("replaying spool: %v", err)
+		return false
+	}
+	p.log.Info("replayed spool")
+
+	return true
+}
+
+// observeSpool updates the spool metrics.
+func (p Poller) observeSpool() {
+	if p.spool == nil {
+		return
+	}
+
+	metrics.SpoolQuotes.Set(float64(p.spool.Len()))
+
+	var age time.Duration
+	if oldest := p.spool.Oldest(); !oldest.IsZero() {
+		age = time.Since(oldest)
+	}
+	metrics.SpoolOldestAge.Set(age.Seconds())
+}
+
+// dedup returns the quotes whose timestamps differ from the last quote
+// archived for their symbols, dropping repeats within the quotes, too.
+func (p Poller) dedup(quotes []finance.Quote) []finance.Quote {
+	var (
+		out  = make([]finance.Quote, 0, len(quotes))
+		seen = make(map[string]time.Time, len(quotes))
+	)
+	for _, q := range quotes {
+		symbol := strings.ToLower(q.Symbol)
+		if t, ok := seen[symbol]; ok && t.Equal(q.Time) {
+			metrics.DuplicateQuotes.WithLabelValues("poll").Inc()
+			continue
+		}
+		if t, ok := p.archived[symbol]; ok && t.Equal(q.Time) {
+			metrics.DuplicateQuotes.WithLabelValues("poll").Inc()
+			continue
+		}
+
+		seen[symbol] = q.Time
+		out = append(out, q)
+	}
+
+	return out
+}
+
+// interval returns the duration between a poll at the given time and the
+// next poll. Without a schedule, that's the default interval. With one, it's
+// the current session's interval, or the default if the session has none,
+// cut short if the next session begins sooner so the next poll happens as it
+// begins (e.g., at the opening bell).
+func (p Poller) interval(now time.Time, interval time.Duration) time.Duration {
+	if p.calendar == nil {
+		return interval
+	}
+
+	if d, ok := p.intervals[p.calendar.Session(now)]; ok && d > 0 {
+		interval = d
+	}
+	if next, _ := p.calendar.Next(now); next.Before(now.Add(interval)) {
+		return next.Sub(now)
+	}
+
+	return interval
+}
+
+// skipped returns true if the schedule skips polling during the session.
+func (p Poller) skipped(session calendar.Session) bool {
+	d, ok := p.intervals[session]
+
+	return ok && d <= 0
+}
+
+// sleep waits for the duration to elapse, returning false if the context is
+// canceled first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// New accepts a finance.Provider, a history.Archiver, a logger, and optional
+// settings, and returns a pointer to a poller Client.
+func New(p finance.Provider, a history.Archiver, l *zap.SugaredLogger,
+	options ...Option) (*Poller, error) {
 	switch {
 	case p == nil:
 		return nil, ErrNilProvider
@@ -83,9 +293,18 @@ func New(p finance.Provider, a history.Archiver, l *zap.SugaredLogger) (
 		return nil, ErrNilLogger
 	}
 
-	return &Poller{
+	poller := &Poller{
 		log:      l.Named("poll"),
 		archiver: a,
 		provider: p,
-	}, nil
+		archived: make(map[string]time.Time),
+	}
+
+	for _, option := range options {
+		if option != nil {
+			option(poller)
+		}
+	}
+
+	return poller, nil
 }
@@ -2,15 +2,27 @@ package poll
 
 import (
 	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/awoodbeck/faang-stonks/calendar"
 	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/awoodbeck/faang-stonks/finance/simulated"
 	"github.com/awoodbeck/faang-stonks/history"
+	"github.com/awoodbeck/faang-stonks/history/memory"
+	"github.com/awoodbeck/faang-stonks/pubsub"
+	"github.com/awoodbeck/faang-stonks/spool"
 	"go.uber.org/zap/zaptest"
 )
 
+// dec parses a Decimal constant, such as a price.
+var dec = finance.MustParseDecimal
+
 func TestNewPoller(t *testing.T) {
 	t.Parallel()
 
@@ -39,14 +51,14 @@ func TestPoller(t *testing.T) {
 
 	now := time.Now()
 	expected := []finance.Quote{
-		{Price: 123.42, Symbol: "fb", Time: now},
-		{Price: 123.45, Symbol: "fb", Time: now},
+		{Price: dec("123.42"), Symbol: "fb", Time: now.Add(time.Second)},
+		{Price: dec("123.45"), Symbol: "fb", Time: now},
 	}
 	m := &mockProviderArchiver{
 		cancel: cancel,
 		quotes: []finance.Quote{
-			{Price: 123.45, Symbol: "fb", Time: now},
-			{Price: 123.42, Symbol: "fb", Time: now},
+			{Price: dec("123.45"), Symbol: "fb", Time: now},
+			{Price: dec("123.42"), Symbol: "fb", Time: now.Add(time.Second)},
 		},
 		storage: make([]finance.Quote, 0, 2),
 	}
@@ -71,6 +83,425 @@ func TestPoller(t *testing.T) {
 	}
 }
 
+func TestPollerSimulated(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(),
+		350*time.Millisecond)
+	defer cancel()
+
+	start := time.Date(2021, 5, 7, 19, 30, 0, 0, time.UTC)
+	newProvider := func() *simulated.Client {
+		// Each poll advances the clock, so no quote duplicates the last.
+		now := start
+		c, err := simulated.New(simulated.Seed(42),
+			simulated.Clock(func() time.Time {
+				now = now.Add(time.Minute)
+				return now
+			}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	storage, err := memory.New(memory.Symbols([]string{"fb", "goog"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := New(newProvider(), storage, zaptest.NewLogger(t).Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Poll(ctx, 100*time.Millisecond, "fb", "goog")
+
+	actual, err := storage.GetQuotesBatchRange(context.Background(),
+		[]string{"fb", "goog"},
+		history.Range{Order: history.Ascending})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actual["fb"]) < 2 {
+		t.Fatalf("expected at least 2 polls; actual: %d", len(actual["fb"]))
+	}
+
+	// A fresh provider with the same seed replays the archived quotes.
+	expected := make(finance.QuoteBatch)
+	replay := newProvider()
+	for range actual["fb"] {
+		quotes, err := replay.GetQuotes(context.Background(), "fb", "goog")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, q := range quotes {
+			expected[q.Symbol] = append(expected[q.Symbol], q)
+		}
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Error("archived quotes do not equal expected")
+		t.Logf("archived: %#v", actual)
+		t.Logf("expected: %#v", expected)
+	}
+}
+
+func TestPollerPartialQuotes(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now()
+	m := &mockProviderArchiver{
+		cancel:  cancel,
+		quotes:  []finance.Quote{{Price: dec("123.45"), Symbol: "fb", Time: now}},
+		err:     finance.SymbolErrors{"goog": fmt.Errorf("unavailable")},
+		storage: make([]finance.Quote, 0, 1),
+	}
+
+	p, err := New(m, m, zaptest.NewLogger(t).Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Poll(ctx, 100*time.Millisecond, "fb", "goog")
+
+	expected := []finance.Quote{{Price: dec("123.45"), Symbol: "fb", Time: now}}
+	if !reflect.DeepEqual(m.storage, expected) {
+		t.Error("storage does not equal expected")
+		t.Logf("storage:  %#v", m.storage)
+		t.Logf("expected: %#v", expected)
+	}
+}
+
+func TestPollerDedup(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now()
+	m := &mockProviderArchiver{
+		cancel: cancel,
+		quotes: []finance.Quote{
+			{Price: dec("123.45"), Symbol: "fb", Time: now},
+			{Price: dec("123.45"), Symbol: "fb", Time: now},
+			{Price: dec("123.42"), Symbol: "FB", Time: now},
+			{Price: dec("123.47"), Symbol: "fb", Time: now.Add(time.Second)},
+		},
+	}
+
+	hub := pubsub.New()
+	sub := hub.Subscribe("fb")
+	defer sub.Close()
+
+	p, err := New(m, m, zaptest.NewLogger(t).Sugar(), Notify(hub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Poll(ctx, 10*time.Millisecond, "fb")
+
+	expected := []finance.Quote{
+		{Price: dec("123.47"), Symbol: "fb", Time: now.Add(time.Second)},
+		{Price: dec("123.45"), Symbol: "fb", Time: now},
+	}
+	if !reflect.DeepEqual(m.storage, expected) {
+		t.Error("storage does not equal expected")
+		t.Logf("storage:  %#v", m.storage)
+		t.Logf("expected: %#v", expected)
+	}
+
+	for i := len(expected) - 1; i >= 0; i-- {
+		select {
+		case e := <-sub.C():
+			if !reflect.DeepEqual(e.Quote, expected[i]) {
+				t.Errorf("actual: %#v; expected: %#v", e.Quote, expected[i])
+			}
+		default:
+			t.Fatal("archived quote was not published")
+		}
+	}
+	select {
+	case e := <-sub.C():
+		t.Errorf("duplicate quote published: %#v", e.Quote)
+	default:
+	}
+}
+
+func TestPollerNotify(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now()
+	m := &mockProviderArchiver{
+		cancel:  cancel,
+		quotes:  []finance.Quote{{Price: dec("123.45"), Symbol: "fb", Time: now}},
+		storage: make([]finance.Quote, 0, 1),
+	}
+
+	hub := pubsub.New()
+	sub := hub.Subscribe("fb")
+	defer sub.Close()
+
+	p, err := New(m, m, zaptest.NewLogger(t).Sugar(), Notify(hub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Poll(ctx, 100*time.Millisecond, "fb")
+
+	select {
+	case e := <-sub.C():
+		if !reflect.DeepEqual(e.Quote, m.storage[0]) {
+			t.Errorf("actual: %#v; expected: %#v", e.Quote, m.storage[0])
+		}
+	default:
+		t.Error("archived quote was not published")
+	}
+}
+
+func TestPollerSpool(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "stonks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Logf("removing temp dir: %v", err)
+		}
+	}()
+
+	s, err := spool.Open(filepath.Join(dir, "stonks.spool"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The archiver fails to store the first two quotes (the second on
+	// replaying the spool), then recovers.
+	now := time.Now()
+	m := &mockProviderArchiver{
+		cancel:   cancel,
+		failures: 2,
+		quotes: []finance.Quote{
+			{Price: dec("123.45"), Symbol: "fb", Time: now},
+			{Price: dec("123.42"), Symbol: "fb", Time: now.Add(time.Second)},
+			{Price: dec("123.47"), Symbol: "fb", Time: now.Add(2 * time.Second)},
+		},
+	}
+
+	hub := pubsub.New()
+	sub := hub.Subscribe("fb")
+	defer sub.Close()
+
+	p, err := New(m, m, zaptest.NewLogger(t).Sugar(), Notify(hub), Spool(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Poll(ctx, 10*time.Millisecond, "fb")
+
+	// Spooled quotes are archived in order, before newer quotes.
+	expected := []finance.Quote{
+		{Price: dec("123.47"), Symbol: "fb", Time: now.Add(2 * time.Second)},
+		{Price: dec("123.42"), Symbol: "fb", Time: now.Add(time.Second)},
+		{Price: dec("123.45"), Symbol: "fb", Time: now},
+	}
+	if !reflect.DeepEqual(m.storage, expected) {
+		t.Error("storage does not equal expected")
+		t.Logf("storage:  %#v", m.storage)
+		t.Logf("expected: %#v", expected)
+	}
+	if s.Len() != 0 {
+		t.Errorf("actual spooled quotes: %d; expected: 0", s.Len())
+	}
+
+	// Spooled quotes are published as they're spooled.
+	for i := len(expected) - 1; i >= 0; i-- {
+		select {
+		case e := <-sub.C():
+			if !reflect.DeepEqual(e.Quote, expected[i]) {
+				t.Errorf("actual: %#v; expected: %#v", e.Quote, expected[i])
+			}
+		default:
+			t.Fatal("spooled quote was not published")
+		}
+	}
+}
+
+func TestPollerSpoolRejected(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "stonks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Logf("removing temp dir: %v", err)
+		}
+	}()
+
+	s, err := spool.Open(filepath.Join(dir, "stonks.spool"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Close() }()
+
+	// The archiver doesn't track tsla, as after a restart with different
+	// symbols, so it rejects the spooled tsla quote and the polled one.
+	now := time.Now()
+	err = s.Append([]finance.Quote{{Price: dec("345.67"), Symbol: "tsla",
+		Time: now.Add(-time.Minute)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	archiver, err := memory.New(memory.Symbols([]string{"fb"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := &mockProviderArchiver{
+		cancel: cancel,
+		quotes: []finance.Quote{
+			{Price: dec("345.68"), Symbol: "tsla", Time: now},
+			{Price: dec("123.45"), Symbol: "fb", Time: now},
+		},
+	}
+
+	p, err := New(m, archiver, zaptest.NewLogger(t).Sugar(), Spool(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Poll(ctx, 10*time.Millisecond, "fb", "tsla")
+
+	// Rejected quotes are neither spooled nor allowed to hold up the rest.
+	if s.Len() != 0 {
+		t.Errorf("actual spooled quotes: %d; expected: 0", s.Len())
+	}
+	quotes, err := archiver.GetQuotes(context.Background(), "fb", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quotes[0].Price != dec("123.45") {
+		t.Errorf("actual quotes: %#v", quotes)
+	}
+}
+
+func TestPollerRegistry(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now()
+	m := &mockProviderArchiver{
+		cancel: cancel,
+		quotes: []finance.Quote{
+			{Price: dec("123.45"), Symbol: "fb", Time: now},
+			{Price: dec("123.42"), Symbol: "fb", Time: now},
+		},
+	}
+
+	// The registry gains a symbol after the first poll.
+	registry, err := memory.New(memory.Symbols([]string{"fb"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.polled = func() {
+		_ = registry.AddSymbol(context.Background(), "goog")
+	}
+
+	p, err := New(m, m, zaptest.NewLogger(t).Sugar(), Registry(registry))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Poll(ctx, 100*time.Millisecond)
+
+	expected := [][]string{{"fb"}, {"fb", "goog"}}
+	if !reflect.DeepEqual(m.requested, expected) {
+		t.Errorf("actual polled symbols: %v; expected: %v", m.requested, expected)
+	}
+}
+
+func TestPollerSchedule(t *testing.T) {
+	t.Parallel()
+
+	cal, err := calendar.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := new(mockProviderArchiver)
+	p, err := New(m, m, zaptest.NewLogger(t).Sugar(), Schedule(cal,
+		map[calendar.Session]time.Duration{
+			calendar.PreMarket: 5 * time.Minute,
+			calendar.Closed:    0,
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		time     string
+		expected time.Duration
+	}{
+		{"2021-05-07T09:35:00-04:00", time.Minute},      // regular uses default
+		{"2021-05-07T15:59:30-04:00", 30 * time.Second}, // cut short at close
+		{"2021-05-07T05:00:00-04:00", 5 * time.Minute},  // pre-market
+		{"2021-05-07T09:28:00-04:00", 2 * time.Minute},  // opening bell
+		{"2021-05-07T17:00:00-04:00", time.Minute},      // after-hours default
+	} {
+		now, err := time.Parse(time.RFC3339, tc.time)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := p.interval(now, time.Minute); actual != tc.expected {
+			t.Errorf("%s: actual interval: %s; expected: %s", tc.time, actual,
+				tc.expected)
+		}
+	}
+
+	for session, expected := range map[calendar.Session]bool{
+		calendar.Closed:     true,
+		calendar.PreMarket:  false,
+		calendar.Regular:    false,
+		calendar.AfterHours: false,
+	} {
+		if actual := p.skipped(session); actual != expected {
+			t.Errorf("%s: actual skipped: %t; expected: %t", session, actual,
+				expected)
+		}
+	}
+
+	// A poller that skips every session never polls.
+	p, err = New(m, m, zaptest.NewLogger(t).Sugar(), Schedule(cal,
+		map[calendar.Session]time.Duration{
+			calendar.PreMarket:  0,
+			calendar.Regular:    0,
+			calendar.AfterHours: 0,
+			calendar.Closed:     0,
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	p.Poll(ctx, 10*time.Millisecond, "fb")
+
+	if len(m.requested) != 0 {
+		t.Errorf("polled %d times while skipping every session", len(m.requested))
+	}
+}
+
 var (
 	_ finance.Provider = (*mockProviderArchiver)(nil)
 	_ history.Archiver = (*mockProviderArchiver)(nil)
@@ -78,13 +509,22 @@ var (
 
 type mockProviderArchiver struct {
 	cancel          context.CancelFunc
+	err             error
+	failures        int
+	polled          func()
 	quotes, storage []finance.Quote
+	requested       [][]string
 }
 
 func (m mockProviderArchiver) Close() error { return nil }
 
-func (m *mockProviderArchiver) GetQuotes(_ context.Context, _ ...string) (
-	[]finance.Quote, error) {
+func (m *mockProviderArchiver) GetQuotes(_ context.Context,
+	symbols ...string) ([]finance.Quote, error) {
+	m.requested = append(m.requested, symbols)
+	if m.polled != nil {
+		m.polled()
+	}
+
 	var q finance.Quote
 	if len(m.quotes) > 0 {
 		q, m.quotes = m.quotes[0], m.quotes[1:]
@@ -93,11 +533,15 @@ func (m *mockProviderArchiver) GetQuotes(_ context.Context, _ ...string) (
 		m.cancel()
 	}
 
-	return []finance.Quote{q}, nil
+	return []finance.Quote{q}, m.err
 }
 
 func (m *mockProviderArchiver) SetQuotes(_ context.Context,
 	quotes []finance.Quote) error {
+	if m.failures > 0 {
+		m.failures--
+		return fmt.Errorf("archiver unavailable")
+	}
 	m.storage = append(quotes, m.storage...)
 
 	return nil
@@ -0,0 +1,260 @@
+// Package pubsub provides a publish/subscribe hub that fans newly archived
+// stock quotes out to any number of subscribers, such as API clients
+// streaming quotes as they arrive.
+package pubsub
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/awoodbeck/faang-stonks/metrics"
+)
+
+const (
+	// DefaultBacklog is the default number of recently published quotes the
+	// hub keeps for subscribers catching up.
+	DefaultBacklog = 1024
+
+	// DefaultBufferSize is the default number of quotes buffered for each
+	// subscriber.
+	DefaultBufferSize = 64
+)
+
+// Event is a published quote and its sequence number, which increases by one
+// with each quote the hub publishes. Sequence numbers start from the time the
+// hub was created, in nanoseconds since the Unix epoch, so those of a hub
+// created later, such as after a restart, are greater.
+type Event struct {
+	Seq   uint64
+	Quote finance.Quote
+}
+
+// Hub publishes quotes to its subscribers. Publishing never blocks on a slow
+// subscriber: once a subscriber's buffer is full, its oldest quote is dropped
+// to make room for the newest.
+type Hub struct {
+	mu         sync.RWMutex
+	subs       map[*Subscription]struct{}
+	bufferSize int
+
+	backlog     []Event
+	backlogSize int
+	seq         uint64 // the last published sequence number
+	evicted     uint64 // the last sequence number dropped from the backlog
+}
+
+// Publish sends the quotes to every subscriber interested in their symbols.
+func (h *Hub) Publish(quotes []finance.Quote) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, q := range quotes {
+		h.seq++
+		e := Event{Seq: h.seq, Quote: q}
+
+		h.backlog = append(h.backlog, e)
+		if over := len(h.backlog) - h.backlogSize; over > 0 {
+			h.evicted = h.backlog[over-1].Seq
+			h.backlog = h.backlog[over:]
+		}
+
+		for s := range h.subs {
+			s.send(e)
+		}
+	}
+}
+
+// Since returns the events published after the given sequence number for the
+// given symbols, or for every symbol if none are given, oldest first. It
+// returns false if the backlog no longer holds all of them, or if the hub
+// didn't publish the sequence number.
+func (h *Hub) Since(seq uint64, symbols ...string) ([]Event, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if seq < h.evicted || seq > h.seq {
+		return nil, false
+	}
+
+	wanted := make(map[string]struct{}, len(symbols))
+	for _, symbol := range symbols {
+		wanted[strings.ToLower(symbol)] = struct{}{}
+	}
+
+	i := sort.Search(len(h.backlog), func(i int) bool {
+		return h.backlog[i].Seq > seq
+	})
+	var events []Event
+	for _, e := range h.backlog[i:] {
+		if _, ok := wanted[strings.ToLower(e.Quote.Symbol)]; ok || len(wanted) == 0 {
+			events = append(events, e)
+		}
+	}
+
+	return events, true
+}
+
+// Subscribe returns a new subscription to the quotes of the given symbols.
+// Symbols can be added to and removed from the subscription later. The caller
+// must close the subscription when finished with it.
+func (h *Hub) Subscribe(symbols ...string) *Subscription {
+	s := h.subscribe(make(map[string]struct{}, len(symbols)))
+	s.Add(symbols...)
+
+	return s
+}
+
+// SubscribeAll returns a new subscription to every quote. The caller must
+// close the subscription when finished with it.
+func (h *Hub) SubscribeAll() *Subscription {
+	return h.subscribe(nil)
+}
+
+func (h *Hub) subscribe(symbols map[string]struct{}) *Subscription {
+	s := &Subscription{
+		hub:     h,
+		c:       make(chan Event, h.bufferSize),
+		symbols: symbols,
+	}
+
+	h.mu.Lock()
+	h.subs[s] = struct{}{}
+	h.mu.Unlock()
+	metrics.StreamSubscribers.Inc()
+
+	return s
+}
+
+// Subscription receives published quotes until it's closed.
+type Subscription struct {
+	hub     *Hub
+	c       chan Event
+	mu      sync.Mutex
+	symbols map[string]struct{} // nil subscribes to every symbol
+	dropped uint64
+	closed  bool
+}
+
+// Add subscribes to the quotes of the given symbols. It has no effect on a
+// subscription to every quote.
+func (s *Subscription) Add(symbols ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.symbols == nil {
+		return
+	}
+	for _, symbol := range symbols {
+		s.symbols[strings.ToLower(symbol)] = struct{}{}
+	}
+}
+
+// C returns the channel on which the subscription receives quotes. The channel
+// is closed when the subscription is.
+func (s *Subscription) C() <-chan Event {
+	return s.c
+}
+
+// Close unsubscribes from the hub and closes the subscription's channel. It's
+// safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	_, ok := s.hub.subs[s]
+	delete(s.hub.subs, s)
+	s.hub.mu.Unlock()
+
+	if !ok {
+		return
+	}
+	metrics.StreamSubscribers.Dec()
+
+	s.mu.Lock()
+	s.closed = true
+	close(s.c)
+	s.mu.Unlock()
+}
+
+// Dropped returns the number of quotes dropped because the subscriber fell
+// behind.
+func (s *Subscription) Dropped() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.dropped
+}
+
+// Remove unsubscribes from the quotes of the given symbols. Quotes already
+// buffered are still received. It has no effect on a subscription to every
+// quote.
+func (s *Subscription) Remove(symbols ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, symbol := range symbols {
+		delete(s.symbols, strings.ToLower(symbol))
+	}
+}
+
+// send buffers the event if the subscriber wants its quote, dropping the
+// oldest buffered event if the buffer is full.
+func (s *Subscription) send(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed || !s.wants(e.Quote.Symbol) {
+		return
+	}
+
+	for {
+		select {
+		case s.c <- e:
+			return
+		default:
+		}
+
+		// The subscriber may drain the buffer between the two selects, in
+		// which case there's nothing to drop and the next send succeeds.
+		select {
+		case <-s.c:
+			s.dropped++
+			metrics.StreamDroppedQuotes.Inc()
+		default:
+		}
+	}
+}
+
+// wants returns true if the subscription includes the symbol. The caller
+// must hold the subscription's lock.
+func (s *Subscription) wants(symbol string) bool {
+	if s.symbols == nil {
+		return true
+	}
+	_, ok := s.symbols[strings.ToLower(symbol)]
+
+	return ok
+}
+
+// New returns a pointer to a new Hub after applying optional settings.
+//
+// Defaults:
+//     Backlog    = 1024
+//     BufferSize = 64
+func New(options ...Option) *Hub {
+	h := &Hub{
+		subs:        make(map[*Subscription]struct{}),
+		bufferSize:  DefaultBufferSize,
+		backlogSize: DefaultBacklog,
+	}
+
+	for _, option := range options {
+		option(h)
+	}
+
+	h.seq = uint64(time.Now().UnixNano())
+	h.evicted = h.seq
+
+	return h
+}
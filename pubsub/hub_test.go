@@ -0,0 +1,147 @@
+package pubsub
+
+import (
+	"testing"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+)
+
+// dec parses a Decimal constant, such as a price.
+var dec = finance.MustParseDecimal
+
+func TestHubSymbols(t *testing.T) {
+	t.Parallel()
+
+	h := New()
+	all := h.SubscribeAll()
+	defer all.Close()
+	fb := h.Subscribe("FB")
+	defer fb.Close()
+
+	h.Publish([]finance.Quote{
+		{Price: dec("123.45"), Symbol: "fb"},
+		{Price: dec("234.56"), Symbol: "goog"},
+	})
+
+	if e := <-fb.C(); e.Quote.Symbol != "fb" {
+		t.Errorf("actual symbol: %q; expected: %q", e.Quote.Symbol, "fb")
+	}
+	if n := len(fb.C()); n != 0 {
+		t.Errorf("fb subscriber has %d unexpected quotes", n)
+	}
+	if n := len(all.C()); n != 2 {
+		t.Errorf("actual quotes: %d; expected: 2", n)
+	}
+}
+
+func TestSubscriptionAddRemove(t *testing.T) {
+	t.Parallel()
+
+	h := New()
+	s := h.Subscribe()
+	defer s.Close()
+
+	quotes := []finance.Quote{
+		{Price: dec("123.45"), Symbol: "fb"},
+		{Price: dec("234.56"), Symbol: "goog"},
+	}
+
+	h.Publish(quotes)
+	if n := len(s.C()); n != 0 {
+		t.Errorf("empty subscription has %d unexpected quotes", n)
+	}
+
+	s.Add("fb", "GOOG")
+	s.Remove("fb")
+	h.Publish(quotes)
+
+	if e := <-s.C(); e.Quote.Symbol != "goog" {
+		t.Errorf("actual symbol: %q; expected: %q", e.Quote.Symbol, "goog")
+	}
+	if n := len(s.C()); n != 0 {
+		t.Errorf("subscription has %d unexpected quotes", n)
+	}
+}
+
+func TestHubDropOldest(t *testing.T) {
+	t.Parallel()
+
+	h := New(BufferSize(2))
+	s := h.SubscribeAll()
+	defer s.Close()
+
+	h.Publish([]finance.Quote{
+		{Price: dec("1"), Symbol: "fb"},
+		{Price: dec("2"), Symbol: "fb"},
+		{Price: dec("3"), Symbol: "fb"},
+	})
+
+	for _, expected := range []finance.Decimal{dec("2"), dec("3")} {
+		if e := <-s.C(); e.Quote.Price != expected {
+			t.Errorf("actual price: %s; expected: %s", e.Quote.Price, expected)
+		}
+	}
+	if s.Dropped() != 1 {
+		t.Errorf("actual dropped: %d; expected: 1", s.Dropped())
+	}
+}
+
+func TestHubClose(t *testing.T) {
+	t.Parallel()
+
+	h := New()
+	s := h.SubscribeAll()
+	s.Close()
+	s.Close()
+
+	h.Publish([]finance.Quote{{Price: dec("123.45"), Symbol: "fb"}})
+
+	if _, ok := <-s.C(); ok {
+		t.Error("closed subscription received a quote")
+	}
+}
+
+func TestHubSince(t *testing.T) {
+	t.Parallel()
+
+	h := New(Backlog(3))
+	s := h.SubscribeAll()
+	defer s.Close()
+
+	h.Publish([]finance.Quote{
+		{Price: dec("123.45"), Symbol: "fb"},
+		{Price: dec("234.56"), Symbol: "goog"},
+	})
+	first := <-s.C()
+	second := <-s.C()
+	if second.Seq != first.Seq+1 {
+		t.Errorf("actual sequence numbers: %d, %d", first.Seq, second.Seq)
+	}
+
+	events, ok := h.Since(first.Seq, "GOOG")
+	if !ok || len(events) != 1 || events[0] != second {
+		t.Errorf("actual events: %#v, %t", events, ok)
+	}
+	if events, ok = h.Since(second.Seq); !ok || len(events) != 0 {
+		t.Errorf("actual events: %#v, %t", events, ok)
+	}
+
+	// Sequence numbers the hub didn't publish can't be resumed from.
+	for _, seq := range []uint64{0, first.Seq - 2, second.Seq + 1} {
+		if _, ok = h.Since(seq); ok {
+			t.Errorf("resumed from unpublished sequence number %d", seq)
+		}
+	}
+
+	// Nor can those older than the backlog.
+	h.Publish([]finance.Quote{
+		{Price: dec("123.42"), Symbol: "fb"},
+		{Price: dec("123.40"), Symbol: "fb"},
+	})
+	if _, ok = h.Since(first.Seq - 1); ok {
+		t.Error("resumed from an evicted sequence number")
+	}
+	if events, ok = h.Since(first.Seq, "fb"); !ok || len(events) != 2 {
+		t.Errorf("actual events: %#v, %t", events, ok)
+	}
+}
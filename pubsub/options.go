@@ -0,0 +1,23 @@
+package pubsub
+
+type Option func(*Hub)
+
+// Backlog sets the number of recently published quotes the hub keeps for
+// subscribers catching up. Zero keeps none.
+func Backlog(n int) Option {
+	return func(h *Hub) {
+		if n >= 0 {
+			h.backlogSize = n
+		}
+	}
+}
+
+// BufferSize sets the number of quotes buffered for each subscriber before
+// the oldest are dropped.
+func BufferSize(n int) Option {
+	return func(h *Hub) {
+		if n > 0 {
+			h.bufferSize = n
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Package spool provides a durable, append-only buffer of stock quotes that
+// couldn't be archived, so they can be archived in order once the archiver
+// recovers.
+package spool
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/awoodbeck/faang-stonks/history"
+)
+
+var ErrInvalidSpool = fmt.Errorf("invalid spool")
+
+// record is a batch of quotes as written to the spool file, one JSON object
+// per line.
+type record struct {
+	Spooled time.Time       `json:"spooled"`
+	Quotes  []finance.Quote `json:"quotes"`
+}
+
+// Spool buffers batches of quotes in an append-only file, syncing each batch
+// to disk before Append returns. It replays batches in the order they were
+// appended, and empties the file once every batch is archived. A batch may be
+// archived more than once if the process stops mid-replay, which the SQLite
+// and memory archivers ignore, as they don't store duplicate quotes.
+type Spool struct {
+	mu      sync.Mutex
+	f       *os.File
+	records []record
+	quotes  int
+}
+
+// Append writes the batch of quotes to the spool.
+func (s *Spool) Append(quotes []finance.Quote) error {
+	if len(quotes) == 0 {
+		return nil
+	}
+
+	r := record{Spooled: time.Now().UTC(), Quotes: quotes}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("encoding batch: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err = s.f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("appending batch: %w", err)
+	}
+	if err = s.f.Sync(); err != nil {
+		return fmt.Errorf("syncing spool: %w", err)
+	}
+
+	s.records = append(s.records, r)
+	s.quotes += len(quotes)
+
+	return nil
+}
+
+// Close the spool file. Spooled quotes remain in it.
+func (s *Spool) Close() error {
+	return s.f.Close()
+}
+
+// Len returns the number of spooled quotes.
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.quotes
+}
+
+// Oldest returns the time the oldest spooled batch was appended, or the zero
+// time if the spool is empty.
+func (s *Spool) Oldest() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.records) == 0 {
+		return time.Time{}
+	}
+
+	return s.records[0].Spooled
+}
+
+// Replay archives the spooled batches in order, stopping at the first batch
+// the archiver fails to store. A batch the archiver rejects for good (see
+// history.Rejected) is dropped rather than retried, so it can't hold up the
+// batches after it; Replay returns the number of quotes it dropped. It empties
+// the spool file once every batch is archived or dropped.
+func (s *Spool) Replay(ctx context.Context, a history.Archiver) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.records) == 0 {
+		return 0, nil
+	}
+
+	dropped := 0
+	for len(s.records) > 0 {
+		n := len(s.records[0].Quotes)
+		err := a.SetQuotes(ctx, s.records[0].Quotes)
+		switch {
+		case history.Rejected(err):
+			dropped += n
+		case err != nil:
+			return dropped, err
+		}
+
+		s.quotes -= n
+		s.records[0] = record{}
+		s.records = s.records[1:]
+	}
+	s.records = nil
+
+	if err := s.f.Truncate(0); err != nil {
+		return dropped, fmt.Errorf("truncating spool: %w", err)
+	}
+	if err := s.f.Sync(); err != nil {
+		return dropped, fmt.Errorf("syncing spool: %w", err)
+	}
+
+	return dropped, nil
+}
+
+// Open returns a spool backed by the given file, creating it if it doesn't
+// exist. Any batches already in the file are queued for replay. A partially
+// written last batch, as a crash may leave behind, is discarded.
+func Open(file string) (*Spool, error) {
+	f, err := os.OpenFile(file, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("opening spool: %w", err)
+	}
+
+	s := &Spool{f: f}
+	size, err := s.load()
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("%q: %w", file, err)
+	}
+
+	// Drop anything after the last complete batch, so new batches begin on
+	// a line of their own.
+	if err = f.Truncate(size); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("truncating spool: %w", err)
+	}
+
+	return s, nil
+}
+
+// load reads the batches in the spool file and returns the size of the file
+// up to the end of the last complete batch.
+func (s *Spool) load() (int64, error) {
+	var (
+		r    = bufio.NewReader(s.f)
+		size int64
+	)
+	for line := 1; ; line++ {
+		b, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// Anything left is a batch cut short mid-write.
+			return size, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("reading spool: %w", err)
+		}
+		size += int64(len(b))
+
+		if len(bytes.TrimSpace(b)) == 0 {
+			continue
+		}
+
+		var rec record
+		if err = json.Unmarshal(b, &rec); err != nil {
+			return 0, fmt.Errorf("%w: line %d: %v", ErrInvalidSpool, line, err)
+		}
+		s.records = append(s.records, rec)
+		s.quotes += len(rec.Quotes)
+	}
+}
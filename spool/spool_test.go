@@ -0,0 +1,217 @@
+package spool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/awoodbeck/faang-stonks/finance"
+	"github.com/awoodbeck/faang-stonks/history"
+)
+
+// dec parses a Decimal constant, such as a price.
+var dec = finance.MustParseDecimal
+
+func TestSpool(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "stonks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Logf("removing temp dir: %v", err)
+		}
+	}()
+
+	ctx := context.Background()
+	file := filepath.Join(dir, "stonks.spool")
+	now := time.Now().UTC().Truncate(time.Second)
+	batches := [][]finance.Quote{
+		{
+			{Price: dec("123.45"), Symbol: "fb", Time: now},
+			{Price: dec("234.56"), Symbol: "goog", Time: now},
+		},
+		{{Price: dec("123.42"), Symbol: "fb", Time: now.Add(time.Minute)}},
+		{{Price: dec("123.47"), Symbol: "fb", Time: now.Add(2 * time.Minute)}},
+	}
+
+	s, err := Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Oldest().IsZero() {
+		t.Errorf("empty spool has an oldest batch: %s", s.Oldest())
+	}
+	for _, b := range batches[:2] {
+		if err = s.Append(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s.Len() != 3 {
+		t.Errorf("actual spooled quotes: %d; expected: 3", s.Len())
+	}
+	if s.Oldest().IsZero() {
+		t.Error("oldest batch time is zero")
+	}
+
+	// A failing archiver leaves the spool untouched.
+	a := &mockArchiver{failures: 1}
+	if _, err = s.Replay(ctx, a); err == nil {
+		t.Error("expected replay to fail")
+	}
+	if s.Len() != 3 || len(a.batches) != 0 {
+		t.Errorf("failed replay changed the spool: %d quotes", s.Len())
+	}
+
+	// Spooled batches survive a restart, even one that cut the last batch
+	// short.
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = f.WriteString(`{"spooled":"2021-05-07T`); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	s, err = Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Close() }()
+
+	if s.Len() != 3 {
+		t.Errorf("actual spooled quotes after restart: %d; expected: 3", s.Len())
+	}
+	if err = s.Append(batches[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	// Batches replay in the order they were appended, then the spool is
+	// empty.
+	if _, err = s.Replay(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(a.batches, batches) {
+		t.Error("replayed batches do not equal expected")
+		t.Logf("replayed: %#v", a.batches)
+		t.Logf("expected: %#v", batches)
+	}
+	if s.Len() != 0 || !s.Oldest().IsZero() {
+		t.Errorf("spool not empty after replay: %d quotes", s.Len())
+	}
+	if fi, err := os.Stat(file); err != nil || fi.Size() != 0 {
+		t.Errorf("spool file not empty after replay: %v", err)
+	}
+}
+
+func TestSpoolRejectedBatch(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "stonks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Logf("removing temp dir: %v", err)
+		}
+	}()
+
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	batches := [][]finance.Quote{
+		{{Price: dec("123.45"), Symbol: "fb", Time: now}},
+		{{Price: dec("345.67"), Symbol: "tsla", Time: now}},
+		{{Price: dec("123.42"), Symbol: "fb", Time: now.Add(time.Minute)}},
+	}
+
+	s, err := Open(filepath.Join(dir, "stonks.spool"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = s.Close() }()
+
+	for _, b := range batches {
+		if err = s.Append(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The archiver never accepts tsla's quotes, which would otherwise hold up
+	// the batches behind them on every replay.
+	a := &mockArchiver{rejected: "tsla"}
+	dropped, err := s.Replay(ctx, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dropped != 1 {
+		t.Errorf("actual dropped quotes: %d; expected: 1", dropped)
+	}
+	expected := [][]finance.Quote{batches[0], batches[2]}
+	if !reflect.DeepEqual(a.batches, expected) {
+		t.Error("replayed batches do not equal expected")
+		t.Logf("replayed: %#v", a.batches)
+		t.Logf("expected: %#v", expected)
+	}
+	if s.Len() != 0 {
+		t.Errorf("spool not empty after replay: %d quotes", s.Len())
+	}
+}
+
+func TestOpenInvalidSpool(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "stonks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Logf("removing temp dir: %v", err)
+		}
+	}()
+
+	file := filepath.Join(dir, "stonks.spool")
+	if err = ioutil.WriteFile(file, []byte("corrupt\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Open(file); !errors.Is(err, ErrInvalidSpool) {
+		t.Errorf("expected ErrInvalidSpool; actual: %v", err)
+	}
+}
+
+type mockArchiver struct {
+	batches  [][]finance.Quote
+	failures int
+	rejected string // symbol whose quotes are always rejected
+}
+
+func (m *mockArchiver) Close() error { return nil }
+
+func (m *mockArchiver) SetQuotes(_ context.Context,
+	quotes []finance.Quote) error {
+	if m.failures > 0 {
+		m.failures--
+		return fmt.Errorf("archiver unavailable")
+	}
+	for _, q := range quotes {
+		if q.Symbol == m.rejected {
+			return fmt.Errorf("symbol %q: %w", q.Symbol, history.ErrRejected)
+		}
+	}
+	m.batches = append(m.batches, quotes)
+
+	return nil
+}